@@ -1,161 +1,751 @@
 package puff
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 )
 
-// handling add command for featured repos
-func addFeatured(cfgDir string, repo Repo, ghPat string) error {
-	release, err := GetLatestRelease(&repo, ghPat)
+// AddOptions holds optional settings for add command
+type AddOptions struct {
+	// install every executable found in custom repo archive
+	AllBinaries bool
+	// name of installed binary for custom repo
+	BinName string
+	// additional names symlinked to the binary
+	Aliases []string
+	// minisign, GPG or SSH public key pinned for package
+	PublicKey string
+	// refuse to install without valid signature, saved for next updates
+	RequireSignature bool
+	// refuse to install without valid signature, not saved (used by upd)
+	RequireSignatureOnce bool
+	// asset name regexp overriding built in or learned one
+	Regexp string
+	// release tag to install and pin instead of latest
+	Tag string
+	// version range that updates must stay in, * removes saved one
+	Constraint string
+	// ask before installing new major version
+	ConfirmMajor bool
+	// release channel: stable, prerelease or regexp matching tag name
+	Channel string
+	// how many releases are searched for matching asset
+	Depth int
+	// semver to order releases by version, date by publishing date
+	Order string
+	// only releases with tags starting with prefix are considered
+	TagPrefix string
+	// install release even if its version is already installed
+	Reinstall bool
+}
+
+// sets version constraint, release channel, search depth and release order
+// of repo from options or metadata
+func applyReleasePolicy(repo *Repo, entry *Metadata, opts *AddOptions) {
+	if entry != nil {
+		repo.Constraint = entry.Constraint
+		repo.Channel = entry.Channel
+		repo.Depth = entry.Depth
+		repo.Order = entry.Order
+		if entry.TagPrefix != "" {
+			repo.TagPrefix = entry.TagPrefix
+		}
+	}
+	switch opts.Constraint {
+	case "":
+	case "*":
+		repo.Constraint = ""
+	default:
+		repo.Constraint = opts.Constraint
+	}
+	switch opts.Channel {
+	case "":
+	case StableChannel:
+		repo.Channel = ""
+	default:
+		repo.Channel = opts.Channel
+	}
+	if opts.Depth != 0 {
+		repo.Depth = opts.Depth
+	}
+	if opts.TagPrefix != "" {
+		repo.TagPrefix = opts.TagPrefix
+	}
+	switch opts.Order {
+	case "":
+	case DateOrder:
+		repo.Order = ""
+	default:
+		repo.Order = opts.Order
+	}
+}
+
+// stores release policy of repo in metadata entry, returns true if it changed
+func saveReleasePolicy(entry *Metadata, repo *Repo) bool {
+	changed := entry.Constraint != repo.Constraint || entry.Channel != repo.Channel ||
+		entry.Depth != repo.Depth || entry.Order != repo.Order ||
+		entry.TagPrefix != savedTagPrefix(repo)
+	entry.Constraint = repo.Constraint
+	entry.Channel = repo.Channel
+	entry.Depth = repo.Depth
+	entry.Order = repo.Order
+	entry.TagPrefix = savedTagPrefix(repo)
+	return changed
+}
+
+// sets asset regexp overriding the built in one from options or metadata,
+// returns true if regexp changed and current version has to be reinstalled
+func applyRegexpOverride(repo *Repo, entry *Metadata, opts *AddOptions) bool {
+	if opts.Regexp != "" {
+		repo.Regexp = opts.Regexp
+		if entry != nil && entry.Regexp != opts.Regexp {
+			fmt.Printf("asset regexp changed to %s, reinstalling\n", opts.Regexp)
+			return true
+		}
+	} else if entry != nil && entry.Regexp != "" {
+		repo.Regexp = entry.Regexp
+	}
+	return false
+}
+
+// returned by installRelease when user declines major version update,
+// callers install newest release with installed major version instead
+var errMajorDeclined = errors.New("major version update declined")
+
+// returns copy of repo limited to releases with major version of installed one
+func sameMajorRepo(repo Repo, installed string) Repo {
+	version, _ := ParseVersion(installed)
+	constraint := fmt.Sprintf("^%d", version.Major)
+	if repo.Constraint != "" {
+		constraint = repo.Constraint + ", " + constraint
+	}
+	repo.Constraint = constraint
+	return repo
+}
+
+// returns copy of options installing major version updates without asking
+func withoutMajorCheck(opts *AddOptions) *AddOptions {
+	noCheckOpts := *opts
+	noCheckOpts.ConfirmMajor = false
+	return &noCheckOpts
+}
+
+// returns copy of options forcing reinstall of current version
+func withReinstall(opts *AddOptions) *AddOptions {
+	reinstallOpts := *opts
+	reinstallOpts.Reinstall = true
+	return &reinstallOpts
+}
+
+// sets public key and signature policy of repo,
+// key passed in options takes precedence over key pinned in metadata
+func applySignaturePolicy(repo *Repo, entry *Metadata, opts *AddOptions) {
+	if opts.PublicKey != "" {
+		repo.PublicKey = opts.PublicKey
+	} else if entry != nil && entry.PublicKey != "" {
+		repo.PublicKey = entry.PublicKey
+	}
+	repo.RequireSignature = repo.RequireSignature || opts.RequireSignature || opts.RequireSignatureOnce
+	if entry != nil && entry.RequireSignature {
+		repo.RequireSignature = true
+	}
+}
+
+// creates alias symlinks in bin pointing to first of files,
+// removes aliases that are not wanted anymore,
+// only aliases belonging to package at path are replaced or removed,
+// files of its previous version may follow the first one
+func linkAliases(cfgDir string, path string, files []string, oldAliases []string, newAliases []string) error {
+	binDir := filepath.Join(cfgDir, "bin")
+	for _, alias := range oldAliases {
+		if slices.Contains(newAliases, alias) {
+			continue
+		}
+		if checkBinOwner(cfgDir, path, alias, files) != nil {
+			fmt.Printf("keeping %s alias, it belongs to another package\n", alias)
+			continue
+		}
+		fmt.Printf("removing %s alias\n", alias)
+		err := os.Remove(filepath.Join(binDir, alias))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	target := files[0]
+	for _, alias := range newAliases {
+		if alias == target {
+			continue
+		}
+		err := checkBinOwner(cfgDir, path, alias, files)
+		if err != nil {
+			return fmt.Errorf("cannot create alias: %w", err)
+		}
+		aliasPath := filepath.Join(binDir, alias)
+		fmt.Printf("linking %s -> %s\n", alias, target)
+		// replace symlink atomically
+		tempPath := aliasPath + ".tmp"
+		os.Remove(tempPath)
+		err = os.Symlink(target, tempPath)
+		if err != nil {
+			return err
+		}
+		err = os.Rename(tempPath, aliasPath)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removes files from bin that were installed before but are not anymore
+func removeStaleFiles(cfgDir string, oldFiles []string, newFiles []string) error {
+	for _, f := range oldFiles {
+		if slices.Contains(newFiles, f) {
+			continue
+		}
+		fmt.Printf("removing %s binary, not present in new version\n", f)
+		err := os.Remove(filepath.Join(cfgDir, "bin", f))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// how many lines of release notes are printed for each release
+const releaseNotesLines = 30
+
+// checks if new version has higher major version than installed one
+func IsMajorBump(installed string, latest string) bool {
+	installedVer, ok := ParseVersion(installed)
+	if !ok {
+		return false
+	}
+	latestVer, ok := ParseVersion(latest)
+	return ok && latestVer.Major > installedVer.Major
+}
+
+// prints release notes between installed and new version
+// and asks user to confirm major version update
+func confirmMajor(repo *Repo, installed string, latest string, ghPat string) bool {
+	fmt.Printf("\n%s: major version update %s -> %s\n", repo.Path, installed, latest)
+	releases, err := ReleasesBetween(repo, installed, latest, ghPat)
+	if err != nil {
+		fmt.Printf("could not get release notes: %v\n", err)
+	}
+	for _, release := range releases {
+		fmt.Printf("\n## %s\n", release.Version)
+		lines := strings.Split(strings.TrimSpace(strings.ReplaceAll(release.Body, "\r\n", "\n")), "\n")
+		if len(lines) > releaseNotesLines {
+			lines = append(lines[:releaseNotesLines], "...")
+		}
+		fmt.Println(strings.Join(lines, "\n"))
+	}
+	var answer string
+	fmt.Printf("\nInstall %s %s? [y/N] ", repo.Path, latest)
+	fmt.Scanln(&answer)
+	return strings.EqualFold(answer, "y") || strings.EqualFold(answer, "yes")
+}
+
+// prints if repo gets pinned to tag or unpinned
+func printPinChange(repo *Repo) {
+	if repo.Tag == "" {
+		fmt.Printf("%s unpinned, it will be updated to latest releases\n", repo.Path)
+	} else {
+		fmt.Printf("%s pinned to %s, upd will not update it\n", repo.Path, repo.Tag)
+	}
+}
+
+// installs release if it's not installed yet and saves metadata
+func installRelease(
+	cfgDir string,
+	metadata *MetadataList,
+	repo *Repo,
+	release *Release,
+	nameParts []string,
+	ghPat string,
+	opts *AddOptions,
+) error {
+	var oldFiles, oldAliases []string
+	var oldVersion string
+	var previous *Metadata
+	if entry := GetMetaEntry(metadata, repo.Path); entry != nil {
+		oldFiles = InstalledFiles(entry)
+		oldAliases = entry.Aliases
+		oldVersion = entry.Version
+		snapshot := *entry
+		previous = &snapshot
+	}
+	var added bool
+	var err error
+	if opts.Reinstall && previous != nil {
+		fmt.Printf("reinstalling %s at version %s\n", repo.Path, release.Version)
+		GetMetaEntry(metadata, repo.Path).Version = release.Version
+		added = true
+	} else {
+		added, err = AddMetaIfNotExists(metadata, repo, release, nameParts)
+		if err != nil {
+			return err
+		}
+	}
+	entry := GetMetaEntry(metadata, repo.Path)
+	if !added {
+		fmt.Printf("%s at version %s already installed\n", repo.Path, entry.Version)
+		changed := false
+		if !slices.Equal(oldAliases, repo.Aliases) {
+			err = linkAliases(cfgDir, repo.Path, InstalledFiles(entry), oldAliases, repo.Aliases)
+			if err != nil {
+				return err
+			}
+			entry.Aliases = repo.Aliases
+			changed = true
+		}
+		if entry.Tag != repo.Tag {
+			printPinChange(repo)
+			entry.Tag = repo.Tag
+			changed = true
+		}
+		if saveReleasePolicy(entry, repo) {
+			changed = true
+		}
+		if opts.PublicKey != "" && opts.PublicKey != entry.PublicKey {
+			fmt.Println("pinning public key, it will verify next updates")
+			entry.PublicKey = opts.PublicKey
+			changed = true
+		}
+		if opts.RequireSignature && !entry.RequireSignature {
+			fmt.Println("valid signature will be required by next updates")
+			entry.RequireSignature = true
+			changed = true
+		}
+		if !changed {
+			return nil
+		}
+		return SaveMetadata(metadata, cfgDir)
+	}
+	if opts.ConfirmMajor && IsMajorBump(oldVersion, release.Version) && !confirmMajor(repo, oldVersion, release.Version, ghPat) {
+		fmt.Printf("%s stays at major version of %s, run puff upd --allow-major to update\n", repo.Path, oldVersion)
+		*entry = *previous
+		return errMajorDeclined
+	}
+	if oldVersion != "" {
+		err = adoptLegacyVersion(cfgDir, previous)
+		if err != nil {
+			return err
+		}
+	}
+	installed, err := DownloadBinary(cfgDir, repo, release, ghPat)
+	if err != nil {
+		return err
+	}
+	err = ActivateVersion(cfgDir, repo.Path, release.Version, installed.Files, oldFiles)
+	if err != nil {
+		return err
+	}
+	err = removeStaleFiles(cfgDir, oldFiles, installed.Files)
+	if err != nil {
+		return err
+	}
+	err = linkAliases(cfgDir, repo.Path, slices.Concat(installed.Files, oldFiles), oldAliases, repo.Aliases)
+	if err != nil {
+		return err
+	}
+	entry.Files = installed.Files
+	entry.Sha256 = installed.Sha256
+	entry.AllBinaries = repo.AllBinaries
+	entry.BinName = repo.BinName
+	entry.Aliases = repo.Aliases
+	entry.Signature = installed.Signature
+	entry.Provenance = installed.Provenance
+	entry.Platform = CurrentPlatform().String()
+	entry.Regexp = savedRegexp(repo)
+	entry.Available = ""
+	if oldVersion != "" && oldVersion != release.Version {
+		entry.History = pushHistory(cfgDir, previous, release.Version)
+	}
+	if entry.Tag != repo.Tag {
+		printPinChange(repo)
+		entry.Tag = repo.Tag
+	}
+	saveReleasePolicy(entry, repo)
+	if opts.PublicKey != "" {
+		entry.PublicKey = opts.PublicKey
+	}
+	if opts.RequireSignature {
+		entry.RequireSignature = true
+	}
+	err = SaveMetadata(metadata, cfgDir)
 	if err != nil {
 		return err
 	}
-	fmt.Printf("latest version: %s\n", release.Version)
+	fmt.Printf(
+		"%s at version %s successfully installed!\n",
+		repo.Path,
+		release.Version,
+	)
+	return nil
+}
+
+// handling add command for featured repos
+func addFeatured(cfgDir string, repo Repo, ghPat string, opts *AddOptions) error {
 	metadata, err := GetMetadata(cfgDir)
 	if err != nil {
 		return err
 	}
-	added, err := AddMetaIfNotExists(metadata, &repo, release, nil)
+	entry := GetMetaEntry(metadata, repo.Path)
+	if applyRegexpOverride(&repo, entry, opts) {
+		opts = withReinstall(opts)
+	}
+	applyReleasePolicy(&repo, entry, opts)
+	repo.Tag = opts.Tag
+	release, err := GetLatestRelease(&repo, ghPat)
 	if err != nil {
 		return err
 	}
-	if added {
-		err := DownloadBinary(cfgDir, &repo, release, ghPat)
-		if err != nil {
-			return err
-		}
-		err = SaveMetadata(metadata, cfgDir)
+	if repo.Tag == "" && repo.Constraint == "" && repo.Channel == "" {
+		fmt.Printf("latest version: %s\n", release.Version)
+	}
+	if opts.Aliases != nil {
+		repo.Aliases = opts.Aliases
+	} else if entry != nil {
+		repo.Aliases = entry.Aliases
+	}
+	applySignaturePolicy(&repo, entry, opts)
+	err = installRelease(cfgDir, metadata, &repo, release, nil, ghPat, opts)
+	if errors.Is(err, errMajorDeclined) {
+		majorRepo := sameMajorRepo(repo, entry.Version)
+		release, err = GetLatestRelease(&majorRepo, ghPat)
 		if err != nil {
 			return err
 		}
-		fmt.Printf(
-			"%s at version %s successfully installed!\n",
-			repo.Path,
-			release.Version,
-		)
+		err = installRelease(cfgDir, metadata, &repo, release, nil, ghPat, withoutMajorCheck(opts))
+	}
+	if err != nil {
+		return err
+	}
+	return skippedReleaseError(repo.Path, release, entry != nil)
+}
+
+// returns error if installed package could not reach newest release
+// because none of its assets matched, so upd warns about renamed assets
+// even though an older release was found
+func skippedReleaseError(path string, release *Release, wasInstalled bool) error {
+	if !wasInstalled || release.SkippedVersion == "" {
+		return nil
+	}
+	return fmt.Errorf("%w in %s release %s, using %s", ErrNoMatchingAsset, path, release.SkippedVersion, release.Version)
+}
+
+// builds Repo for custom repo from its metadata entry and add options,
+// returns true if changed asset regexp requires reinstall
+func customRepo(metadata *MetadataList, path string, opts *AddOptions) (Repo, bool) {
+	isAdded := IsCustomRepoAdded(metadata, path)
+	repo := Repo{
+		Path:        path,
+		AllBinaries: isAdded.AllBinaries || opts.AllBinaries,
+		BinName:     isAdded.BinName,
+		Aliases:     isAdded.Aliases,
+		Tag:         opts.Tag,
+	}
+	if opts.BinName != "" {
+		repo.BinName = opts.BinName
+	}
+	if opts.Aliases != nil {
+		repo.Aliases = opts.Aliases
+	}
+	entry := GetMetaEntry(metadata, path)
+	reinstall := applyRegexpOverride(&repo, entry, opts)
+	applyReleasePolicy(&repo, entry, opts)
+	applySignaturePolicy(&repo, entry, opts)
+	return repo, reinstall
+}
+
+// finds release of custom repo and picks its asset,
+// asset regexp is learned if none is saved,
+// returns nil release if there is nothing to install
+func customRelease(repo *Repo, savedParts []string, ghPat string) (*Release, []string, error) {
+	var ghResp *GithubResponse
+	var err error
+	if repo.Regexp != "" || savedParts != nil {
+		// older releases are searched if saved pattern matches no asset
+		ghResp, err = WalkReleases(repo, ghPat, func(r *GithubResponse) bool {
+			asset, _, err := PickCustomAsset(r.Assets, repo.Regexp, savedParts)
+			return err == nil && asset != nil
+		})
 	} else {
-		fmt.Printf("%s at version %s already installed\n", repo.Path, release.Version)
+		ghResp, err = GetRepoRelease(repo, ghPat)
 	}
-	return nil
+	if err != nil {
+		return nil, nil, err
+	}
+	if ghResp == nil {
+		fmt.Println("no release found")
+		return nil, nil, nil
+	}
+	if len(ghResp.Assets) == 0 {
+		fmt.Println("no assets found in release")
+		return nil, nil, nil
+	}
+	asset, nameParts, err := PickCustomAsset(ghResp.Assets, repo.Regexp, savedParts)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s release %s: %w", repo.Path, ghResp.Version, err)
+	}
+	if repo.Regexp == "" {
+		repo.Regexp = LearnAssetRegexp(asset.Name, ghResp.Version)
+		fmt.Printf("asset pattern %s saved for next updates\n", repo.Regexp)
+	}
+	return &Release{
+		Version:        ghResp.Version,
+		Name:           asset.Name,
+		Link:           asset.URL,
+		Digest:         asset.Digest,
+		Assets:         ghResp.Assets,
+		SkippedVersion: ghResp.SkippedVersion,
+	}, nameParts, nil
 }
 
 // handling add command for custom repos
-func addCustom(cfgDir string, installRepo *string, ghPat string) error {
+func addCustom(cfgDir string, installRepo *string, ghPat string, opts *AddOptions) error {
 	fmt.Println("binary not found in featured repos")
-	ghResp, err := GetLatestReleaseAssets(*installRepo, ghPat)
+	metadata, err := GetMetadata(cfgDir)
 	if err != nil {
 		return err
 	}
-	if ghResp != nil {
-		if ghResp.Assets != nil {
-			if len(ghResp.Assets) == 0 {
-				fmt.Println("no assets found in release")
-				return nil
-			}
-			metadata, err := GetMetadata(cfgDir)
-			if err != nil {
-				return err
-			}
-			isAdded := IsCustomRepoAdded(metadata, *installRepo)
-			repo := Repo{Path: *installRepo}
-			var nameParts []string
-			if isAdded.Version != "" {
-				fmt.Printf("%s custom repo already added\n", *installRepo)
-				nameParts = isAdded.NameParts
-			} else {
-				fmt.Println("\nAvailable binaries:")
-				for _, v := range ghResp.Assets {
-					fmt.Println(v.Name)
-				}
-				nameParts = PromptForNameParts()
-			}
-			for _, asset := range ghResp.Assets {
-				containsAll := false
-				for _, part := range nameParts {
-					if strings.Contains(asset.Name, part) {
-						containsAll = true
-					} else {
-						containsAll = false
-						break
-					}
-				}
-				if containsAll {
-					metadata, err := GetMetadata(cfgDir)
-					if err != nil {
-						return err
-					}
-					release := &Release{
-						Version: ghResp.Version,
-						Link:    asset.URL,
-					}
-					added, err := AddMetaIfNotExists(metadata,
-						&repo,
-						release,
-						nameParts,
-					)
-					if added {
-						err := DownloadBinary(cfgDir, &repo, release, ghPat)
-						if err != nil {
-							return err
-						}
-						err = SaveMetadata(metadata, cfgDir)
-						if err != nil {
-							return err
-						}
-						fmt.Printf(
-							"%s at version %s successfully installed!\n",
-							repo.Path,
-							release.Version,
-						)
-					} else {
-						fmt.Printf("%s at version %s already installed\n", repo.Path, release.Version)
-					}
-					break
-				}
-			}
-		} else {
-			fmt.Println("no assets found in release")
+	isAdded := IsCustomRepoAdded(metadata, *installRepo)
+	repo, reinstall := customRepo(metadata, *installRepo, opts)
+	if reinstall {
+		opts = withReinstall(opts)
+	}
+	release, nameParts, err := customRelease(&repo, isAdded.NameParts, ghPat)
+	if err != nil || release == nil {
+		return err
+	}
+	if isAdded.Version != "" {
+		fmt.Printf("%s custom repo already added\n", *installRepo)
+	}
+	err = installRelease(cfgDir, metadata, &repo, release, nameParts, ghPat, opts)
+	if errors.Is(err, errMajorDeclined) {
+		majorRepo := sameMajorRepo(repo, isAdded.Version)
+		release, nameParts, err = customRelease(&majorRepo, isAdded.NameParts, ghPat)
+		if err != nil || release == nil {
+			return err
 		}
-	} else {
-		fmt.Println("no release found")
+		err = installRelease(cfgDir, metadata, &repo, release, nameParts, ghPat, withoutMajorCheck(opts))
 	}
-	return nil
+	if err != nil {
+		return err
+	}
+	return skippedReleaseError(repo.Path, release, isAdded.Version != "")
 }
 
-// handling add command
-func Add(cfgDir string, installRepo *string, ghPat string) error {
-	fmt.Printf("installing %s\n", *installRepo)
-	found := false
-	for _, repo := range *AvailableRepos() {
-		if repo.Path == *installRepo {
-			if err := addFeatured(cfgDir, repo, ghPat); err != nil {
-				return err
-			}
-			found = true
-			break
-		}
+// ReconfigureOptions holds asset selection for reconfigure command,
+// user is asked interactively if both are empty
+type ReconfigureOptions struct {
+	// strings that asset name must contain
+	Match []string
+	// asset name regexp
+	Regexp string
+}
+
+// handling reconfigure command, changes asset selection of custom repo
+// and reinstalls it with the new rule
+func Reconfigure(cfgDir string, path string, ghPat string, opts *ReconfigureOptions) error {
+	if IsFeaturedRepo(path) {
+		return fmt.Errorf("%s is a featured repo, its asset pattern is built in", path)
+	}
+	metadata, err := GetMetadata(cfgDir)
+	if err != nil {
+		return err
+	}
+	entry := GetMetaEntry(metadata, path)
+	if entry == nil {
+		return fmt.Errorf("%s is not installed", path)
+	}
+	repo, _ := customRepo(metadata, path, &AddOptions{Tag: entry.Tag})
+	ghResp, err := GetRepoRelease(&repo, ghPat)
+	if err != nil {
+		return err
+	}
+	if ghResp == nil || len(ghResp.Assets) == 0 {
+		return fmt.Errorf("no assets found in release of %s", path)
 	}
-	if !found {
-		if err := addCustom(cfgDir, installRepo, ghPat); err != nil {
+	fmt.Printf("current asset pattern: %s\n", entry.Regexp)
+	fmt.Printf("\nAvailable binaries in %s:\n", ghResp.Version)
+	for _, v := range ghResp.Assets {
+		fmt.Println(v.Name)
+	}
+	nameParts := opts.Match
+	var asset *Asset
+	if opts.Regexp != "" {
+		asset, err = MatchRegexp(ghResp.Assets, opts.Regexp)
+		if err != nil {
 			return err
 		}
+		nameParts = nil
+	} else {
+		if nameParts == nil {
+			nameParts = PromptForNameParts()
+		}
+		asset = MatchNameParts(ghResp.Assets, nameParts)
 	}
-	return nil
+	if asset == nil {
+		return fmt.Errorf("%w in %s release %s", ErrNoMatchingAsset, path, ghResp.Version)
+	}
+	fmt.Printf("picked %s\n", asset.Name)
+
+	repo.Regexp = opts.Regexp
+	if repo.Regexp == "" {
+		repo.Regexp = LearnAssetRegexp(asset.Name, ghResp.Version)
+	}
+	fmt.Printf("asset pattern %s saved for next updates\n", repo.Regexp)
+	entry.NameParts = nameParts
+	release := &Release{
+		Version: ghResp.Version,
+		Name:    asset.Name,
+		Link:    asset.URL,
+		Digest:  asset.Digest,
+		Assets:  ghResp.Assets,
+	}
+	return installRelease(cfgDir, metadata, &repo, release, nameParts, ghPat, &AddOptions{Reinstall: true})
+}
+
+// handling add command, repo can be given as owner/repo@tag
+// to install and pin release with that tag
+func Add(cfgDir string, installRepo *string, ghPat string, opts *AddOptions) error {
+	path, tag, _ := strings.Cut(*installRepo, "@")
+	if tag != "" {
+		tagOpts := *opts
+		tagOpts.Tag = tag
+		opts = &tagOpts
+	}
+	fmt.Printf("installing %s\n", *installRepo)
+	if repo := GetFeaturedRepo(path); repo != nil {
+		return addFeatured(cfgDir, *repo, ghPat, opts)
+	}
+	return addCustom(cfgDir, &path, ghPat, opts)
+}
+
+// handling hold and unhold commands,
+// held packages are not updated by upd
+func SetHeld(cfgDir string, path string, held bool) error {
+	metadata, err := GetMetadata(cfgDir)
+	if err != nil {
+		return err
+	}
+	entry := GetMetaEntry(metadata, path)
+	if entry == nil {
+		return fmt.Errorf("%s is not installed", path)
+	}
+	if entry.Held == held {
+		fmt.Printf("%s already %s\n", path, heldState(held))
+		return nil
+	}
+	entry.Held = held
+	if !held {
+		entry.Available = ""
+	}
+	fmt.Printf("%s %s at version %s\n", path, heldState(held), entry.Version)
+	return SaveMetadata(metadata, cfgDir)
+}
+
+// describes hold state
+func heldState(held bool) string {
+	if held {
+		return "held"
+	}
+	return "unheld"
+}
+
+// checks latest release of held package
+// and saves its version in metadata if it differs from installed one
+func checkHeld(cfgDir string, m *Metadata, ghPat string) error {
+	fmt.Printf("%s held at version %s\n", m.Path, m.Version)
+	repo := Repo{
+		Path:       m.Path,
+		Constraint: m.Constraint,
+		Channel:    m.Channel,
+		Order:      m.Order,
+		TagPrefix:  m.TagPrefix,
+	}
+	if featured := GetFeaturedRepo(m.Path); featured != nil && m.TagPrefix == "" {
+		repo.TagPrefix = featured.TagPrefix
+	}
+	latest, err := GetRepoRelease(&repo, ghPat)
+	if err != nil {
+		return err
+	}
+	if latest == nil || latest.Version == m.Version {
+		return nil
+	}
+	if cmp, isSemver := CompareTags(latest.Version, m.Version); isSemver && cmp <= 0 {
+		// older or re-tagged release is not an update
+		return nil
+	}
+	fmt.Printf("update available: %s, held\n", latest.Version)
+	if latest.Version == m.Available {
+		return nil
+	}
+	metadata, err := GetMetadata(cfgDir)
+	if err != nil {
+		return err
+	}
+	entry := GetMetaEntry(metadata, m.Path)
+	if entry == nil {
+		return nil
+	}
+	entry.Available = latest.Version
+	return SaveMetadata(metadata, cfgDir)
+}
+
+// UpdateOptions holds optional settings for upd command
+type UpdateOptions struct {
+	// refuse to install updates without valid signature
+	RequireSignature bool
+	// install major version updates without asking
+	AllowMajor bool
 }
 
 // handling update command
-func Update(cfgDir string, ghPat string, metadata *MetadataList) error {
+func Update(cfgDir string, ghPat string, metadata *MetadataList, opts *UpdateOptions) error {
 	fmt.Print("---\n\n")
+	var unmatched []string
 	for _, m := range metadata.Metadata {
-		err := Add(cfgDir, &m.Path, ghPat)
-		if err != nil {
+		if m.Tag != "" {
+			fmt.Printf("%s pinned to %s, skipping\n", m.Path, m.Tag)
+			fmt.Print("---\n\n")
+			continue
+		}
+		if m.Held {
+			err := checkHeld(cfgDir, &m, ghPat)
+			if err != nil {
+				return err
+			}
+			fmt.Print("---\n\n")
+			continue
+		}
+		err := Add(cfgDir, &m.Path, ghPat, &AddOptions{
+			RequireSignatureOnce: opts.RequireSignature,
+			ConfirmMajor:         !opts.AllowMajor,
+		})
+		if errors.Is(err, ErrNoMatchingAsset) {
+			fmt.Printf("WARNING: %v\nWARNING: %s NOT UPDATED TO NEWEST RELEASE\n", err, m.Path)
+			unmatched = append(unmatched, m.Path)
+		} else if err != nil {
 			return err
 		}
 		fmt.Print("---\n\n")
 	}
+	if len(unmatched) > 0 {
+		fmt.Printf(
+			"WARNING: no asset matched for %s, assets were probably renamed\n\n",
+			strings.Join(unmatched, ", "),
+		)
+	}
 
 	fmt.Println("updating puff")
 	puffRepo := Repo{Path: "pgulb/puff"}
@@ -163,9 +753,25 @@ func Update(cfgDir string, ghPat string, metadata *MetadataList) error {
 	if err != nil {
 		return err
 	}
-	if puffRelease.Version != Version {
+	newer := puffRelease.Version != Version
+	if cmp, isSemver := CompareTags(puffRelease.Version, Version); isSemver {
+		// never downgrade puff
+		newer = cmp > 0
+	}
+	if newer {
 		fmt.Printf("new version available: %s\n", puffRelease.Version)
-		err := DownloadBinary(cfgDir, &puffRepo, puffRelease, ghPat)
+		installed, err := DownloadBinary(cfgDir, &puffRepo, puffRelease, ghPat)
+		if err != nil {
+			return err
+		}
+		// puff installed by script is a regular file in bin
+		err = ActivateVersion(cfgDir, puffRepo.Path, puffRelease.Version, installed.Files, []string{"puff"})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("replaced %s with new version\n", filepath.Join(cfgDir, "bin", "puff"))
+		// running puff keeps its file open, older versions are not needed
+		err = pruneStore(cfgDir, puffRepo.Path, puffRelease.Version)
 		if err != nil {
 			return err
 		}
@@ -176,47 +782,49 @@ func Update(cfgDir string, ghPat string, metadata *MetadataList) error {
 	return nil
 }
 
+// handling rm command, removes every installed file of a repo
 func Remove(cfgDir string, removeRepo *string) error {
 	metadata, err := GetMetadata(cfgDir)
 	if err != nil {
 		return err
 	}
-	for _, v := range metadata.Metadata {
-		if v.Path == *removeRepo {
-			expectedBinary := strings.Split(v.Path, "/")[1]
-			binDir := filepath.Join(cfgDir, "bin")
-			binaries, err := os.ReadDir(binDir)
-			if err != nil {
-				return err
-			}
-			var removed bool
-			for _, v := range binaries {
-				if v.Name() == expectedBinary {
-					fmt.Printf("removing %s binary\n", v.Name())
-					removed = true
-					err := os.Remove(filepath.Join(binDir, v.Name()))
-					if err != nil {
-						return err
-					}
-					break
-				}
-			}
-			if !removed {
-				return fmt.Errorf("binary for %s not found to remove", expectedBinary)
-			}
-			fmt.Printf("removing %s from metadata\n", *removeRepo)
-			var newMeta []Metadata
-			for _, metaEntry := range metadata.Metadata {
-				if metaEntry.Path != *removeRepo {
-					newMeta = append(newMeta, metaEntry)
-				}
-			}
-			metadata.Metadata = newMeta
-			err = SaveMetadata(metadata, cfgDir)
-			if err != nil {
-				return err
+	entry := GetMetaEntry(metadata, *removeRepo)
+	if entry == nil {
+		return fmt.Errorf("%s is not installed", *removeRepo)
+	}
+	binDir := filepath.Join(cfgDir, "bin")
+	err = linkAliases(cfgDir, entry.Path, InstalledFiles(entry), entry.Aliases, nil)
+	if err != nil {
+		return err
+	}
+	var removed bool
+	for _, expectedBinary := range InstalledFiles(entry) {
+		fmt.Printf("removing %s binary\n", expectedBinary)
+		err := os.Remove(filepath.Join(binDir, expectedBinary))
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				fmt.Printf("binary %s not found to remove\n", expectedBinary)
+				continue
 			}
+			return err
 		}
+		removed = true
 	}
-	return nil
+	if !removed {
+		return fmt.Errorf("binaries for %s not found to remove", *removeRepo)
+	}
+	fmt.Printf("removing %s from store\n", *removeRepo)
+	err = removeFromStore(cfgDir, *removeRepo)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("removing %s from metadata\n", *removeRepo)
+	var newMeta []Metadata
+	for _, metaEntry := range metadata.Metadata {
+		if metaEntry.Path != *removeRepo {
+			newMeta = append(newMeta, metaEntry)
+		}
+	}
+	metadata.Metadata = newMeta
+	return SaveMetadata(metadata, cfgDir)
 }
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
 	"strings"
@@ -14,12 +15,62 @@ func printHelp() {
 	fmt.Println("  puff list -> list installed binaries")
 	fmt.Println("  puff search <name (opt.)> -> search pre-added repositories")
 	fmt.Println("  puff add <repo> <repo>... -> install binary from repo(s)")
+	fmt.Println("    <repo>@<tag> -> install release with given tag and pin it")
+	fmt.Println("    --all -> install every executable from custom repo archive")
+	fmt.Println("    --as <name> -> install custom repo binary under given name")
+	fmt.Println("    --alias <name> -> symlink binary under additional name (repeatable)")
+	fmt.Println("    --key <key|@file> -> pin minisign/GPG/SSH public key verifying signatures")
+	fmt.Println("    --require-signature -> fail if no valid signature is found")
+	fmt.Println("    --regex <regexp> -> asset name regexp overriding the built in one")
+	fmt.Println("    --constraint <range> -> keep updates in version range, like ~1.4 or <2.0.0 (* removes it)")
+	fmt.Println("    --channel <stable|prerelease|regexp> -> track prereleases or tags matching regexp, like ^nightly")
+	fmt.Println("    --depth <n> -> search n newest releases for matching asset (default 10)")
+	fmt.Println("    --order <date|semver> -> pick newest release by publishing date or by version")
+	fmt.Println("    --tag-prefix <prefix> -> consider only releases with tags like <prefix>v1.2.3 (monorepos)")
 	fmt.Println("  puff upd -> update all installed binaries")
+	fmt.Println("    --require-signature -> fail if no valid signature is found")
+	fmt.Println("    --allow-major -> install major version updates without asking")
+	fmt.Println("  puff reconfigure <repo> -> change asset picked from custom repo release and reinstall")
+	fmt.Println("    --match <string> -> string that asset name must contain (repeatable)")
+	fmt.Println("    --regex <regexp> -> regexp that asset name must match")
+	fmt.Println("  puff hold <repo> <repo>... -> do not update binary/ies with upd")
+	fmt.Println("  puff unhold <repo> <repo>... -> update binary/ies with upd again")
+	fmt.Println("  puff rollback <repo> -> switch to previously installed version and hold it")
+	fmt.Println("  puff use <repo>@<version> -> switch to version kept in store without downloading")
 	fmt.Println("  puff rm <repo> <repo>... -> remove installed binary/ies")
 	fmt.Println("  puff version|--version|-v -> print puff version")
 	os.Exit(1)
 }
 
+// flag.Value collecting repeated string flags
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(v string) error {
+	*l = append(*l, v)
+	return nil
+}
+
+// parses flags placed anywhere between positional args,
+// returns positional args
+func parseFlags(fs *flag.FlagSet, args []string) []string {
+	var positional []string
+	for {
+		// flag.ExitOnError handles parsing errors
+		_ = fs.Parse(args)
+		args = fs.Args()
+		if len(args) == 0 {
+			break
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+	return positional
+}
+
 func main() {
 	cfgDir := puff.MustCreateCfgDir()
 
@@ -74,7 +125,34 @@ func main() {
 			return
 		} else {
 			for _, v := range metadata.Metadata {
-				fmt.Printf("- %s (version: %s)\n", v.Path, v.Version)
+				fmt.Printf("- %s (version: %s)", v.Path, v.Version)
+				if v.Tag != "" {
+					fmt.Print(" pinned")
+				}
+				if v.Constraint != "" {
+					fmt.Printf(" constraint: %s", v.Constraint)
+				}
+				if v.Channel != "" {
+					fmt.Printf(" channel: %s", v.Channel)
+				}
+				if v.Held && v.Available != "" {
+					fmt.Printf(" update available: %s, held", v.Available)
+				} else if v.Held {
+					fmt.Print(" held")
+				}
+				if len(v.Files) > 1 {
+					fmt.Printf(" [%s]", strings.Join(v.Files, ", "))
+				}
+				if len(v.Aliases) > 0 {
+					fmt.Printf(" aliases: %s", strings.Join(v.Aliases, ", "))
+				}
+				if v.Signature != "" {
+					fmt.Printf(" signed: %s", v.Signature)
+				}
+				if v.Provenance != "" {
+					fmt.Printf(" provenance: %s", v.Provenance)
+				}
+				fmt.Println()
 			}
 		}
 	case "search":
@@ -94,23 +172,96 @@ func main() {
 			}
 		}
 	case "add":
-		if len(os.Args) < 3 {
+		addFlags := flag.NewFlagSet("add", flag.ExitOnError)
+		opts := &puff.AddOptions{}
+		addFlags.BoolVar(&opts.AllBinaries, "all", false, "install every executable from custom repo archive")
+		addFlags.StringVar(&opts.BinName, "as", "", "install custom repo binary under given name")
+		addFlags.Var((*stringList)(&opts.Aliases), "alias", "symlink binary under additional name")
+		addFlags.StringVar(&opts.PublicKey, "key", "", "pin minisign/GPG/SSH public key verifying signatures")
+		addFlags.BoolVar(&opts.RequireSignature, "require-signature", false, "fail if no valid signature is found")
+		addFlags.StringVar(&opts.Regexp, "regex", "", "asset name regexp overriding the built in one")
+		addFlags.StringVar(&opts.Constraint, "constraint", "", "keep updates in version range, like ~1.4 or <2.0.0")
+		addFlags.StringVar(&opts.Channel, "channel", "", "release channel: stable, prerelease or regexp matching tag name")
+		addFlags.IntVar(&opts.Depth, "depth", 0, "search n newest releases for matching asset")
+		addFlags.StringVar(&opts.Order, "order", "", "pick newest release by date or by semver")
+		addFlags.StringVar(&opts.TagPrefix, "tag-prefix", "", "consider only releases with tags starting with prefix")
+		reposToAdd := parseFlags(addFlags, os.Args[2:])
+		if len(reposToAdd) == 0 {
 			printHelp()
 		}
-		reposToAdd := os.Args[2:]
+		if opts.Order != "" && opts.Order != puff.SemverOrder && opts.Order != puff.DateOrder {
+			fmt.Printf("invalid order %s, expected %s or %s\n", opts.Order, puff.DateOrder, puff.SemverOrder)
+			return
+		}
+		if opts.PublicKey != "" {
+			opts.PublicKey, err = puff.ReadPublicKey(opts.PublicKey)
+			if err != nil {
+				fmt.Println(err.Error())
+				return
+			}
+		}
 		for _, installRepo := range reposToAdd {
-			err := puff.Add(cfgDir, &installRepo, ghPat)
+			err := puff.Add(cfgDir, &installRepo, ghPat, opts)
 			if err != nil {
 				fmt.Println(err.Error())
 			}
 		}
 	case "upd":
+		updFlags := flag.NewFlagSet("upd", flag.ExitOnError)
+		opts := &puff.UpdateOptions{}
+		updFlags.BoolVar(&opts.RequireSignature, "require-signature", false, "fail if no valid signature is found")
+		updFlags.BoolVar(&opts.AllowMajor, "allow-major", false, "install major version updates without asking")
+		parseFlags(updFlags, os.Args[2:])
 		fmt.Println("Updating all installed binaries")
 		metadata, err := puff.GetMetadata(cfgDir)
 		if err != nil {
 			fmt.Println(err.Error())
 		}
-		err = puff.Update(cfgDir, ghPat, metadata)
+		err = puff.Update(cfgDir, ghPat, metadata, opts)
+		if err != nil {
+			fmt.Println(err.Error())
+		}
+	case "reconfigure":
+		reconfigureFlags := flag.NewFlagSet("reconfigure", flag.ExitOnError)
+		opts := &puff.ReconfigureOptions{}
+		reconfigureFlags.Var((*stringList)(&opts.Match), "match", "string that asset name must contain")
+		reconfigureFlags.StringVar(&opts.Regexp, "regex", "", "regexp that asset name must match")
+		reposToReconfigure := parseFlags(reconfigureFlags, os.Args[2:])
+		if len(reposToReconfigure) != 1 {
+			printHelp()
+		}
+		err := puff.Reconfigure(cfgDir, reposToReconfigure[0], ghPat, opts)
+		if err != nil {
+			fmt.Println(err.Error())
+		}
+	case "hold", "unhold":
+		if len(os.Args) < 3 {
+			printHelp()
+		}
+		for _, holdRepo := range os.Args[2:] {
+			err := puff.SetHeld(cfgDir, holdRepo, os.Args[1] == "hold")
+			if err != nil {
+				fmt.Println(err.Error())
+			}
+		}
+	case "rollback":
+		if len(os.Args) != 3 {
+			printHelp()
+		}
+		err := puff.Rollback(cfgDir, os.Args[2])
+		if err != nil {
+			fmt.Println(err.Error())
+		}
+	case "use":
+		if len(os.Args) != 3 {
+			printHelp()
+		}
+		useRepo, version, found := strings.Cut(os.Args[2], "@")
+		if !found || version == "" {
+			fmt.Println("version is required, like puff use owner/repo@v1.2.3")
+			os.Exit(1)
+		}
+		err := puff.Use(cfgDir, useRepo, version)
 		if err != nil {
 			fmt.Println(err.Error())
 		}
@@ -1,9 +1,6 @@
 package puff
 
 import (
-	"archive/tar"
-	"bytes"
-	"compress/gzip"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -13,6 +10,7 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -21,16 +19,45 @@ import (
 // Release holds latest version and download link for a Repo
 type Release struct {
 	Version string
-	Link    string
+	// file name of picked asset
+	Name string
+	Link string
+	// digest of picked asset reported by API, like "sha256:<hex>"
+	Digest string
+	// all assets of release, searched for checksums
+	Assets []Asset
+	// newest release skipped because none of its assets matched
+	SkippedVersion string
+}
+
+// Installed holds results of installing a Release
+type Installed struct {
+	Files []string
+	// sha256 of downloaded asset, empty if no checksum was published
+	Sha256 string
+	// verified signature, empty if none was verified
+	Signature string
+	// result of Sigstore and SLSA provenance verification
+	Provenance string
+}
+
+// Asset holds a single release asset from Github API
+type Asset struct {
+	Name   string `json:"name"`
+	URL    string `json:"browser_download_url"`
+	Digest string `json:"digest"`
 }
 
 // GithubResponse holds response from Github API for a release
 type GithubResponse struct {
-	Version string `json:"tag_name"`
-	Assets  []struct {
-		Name string `json:"name"`
-		URL  string `json:"browser_download_url"`
-	}
+	Version    string  `json:"tag_name"`
+	Assets     []Asset `json:"assets"`
+	Prerelease bool    `json:"prerelease"`
+	Draft      bool    `json:"draft"`
+	// release notes
+	Body string `json:"body"`
+	// newest release skipped when walking back, not part of API response
+	SkippedVersion string `json:"-"`
 }
 
 // returns authenticated *http.Client and *http.Request
@@ -45,9 +72,259 @@ func AuthedClient(url string, ghPat string) (*http.Client, *http.Request, error)
 	return c, req, nil
 }
 
-// finds latest version and download link for a Repo
+// returns first asset with name matching regexp or nil if there is none
+func matchingAsset(assets []Asset, validName *regexp.Regexp) *Asset {
+	for i, asset := range assets {
+		if validName.MatchString(asset.Name) {
+			return &assets[i]
+		}
+	}
+	return nil
+}
+
+// finds latest version and download link for a Repo,
+// release with Repo.Tag is used instead of latest if set,
+// older releases are searched if newest one has no matching asset
 func GetLatestRelease(repo *Repo, ghPat string) (*Release, error) {
-	RepoUrl, err := url.JoinPath("https://api.github.com", "repos", repo.Path, "releases/latest")
+	validName, err := regexp.Compile(ResolveRegexp(repo.Regexp, CurrentPlatform()))
+	if err != nil {
+		return nil, err
+	}
+	releaseJson, err := WalkReleases(repo, ghPat, func(r *GithubResponse) bool {
+		return matchingAsset(r.Assets, validName) != nil
+	})
+	if errors.Is(err, ErrNoMatchingAsset) {
+		return nil, fmt.Errorf("%w, no regexp matching name found for %s", err, CurrentPlatform())
+	}
+	if err != nil {
+		return nil, err
+	}
+	asset := matchingAsset(releaseJson.Assets, validName)
+	return &Release{
+		Version:        releaseJson.Version,
+		Name:           asset.Name,
+		Link:           asset.URL,
+		Digest:         asset.Digest,
+		Assets:         releaseJson.Assets,
+		SkippedVersion: releaseJson.SkippedVersion,
+	}, nil
+}
+
+// how many times interrupted download is resumed before giving up
+const downloadAttempts = 5
+
+// io.Writer printing download progress,
+// size is -1 when server did not send Content-Length
+type progressWriter struct {
+	size    int64
+	written int64
+}
+
+func (p *progressWriter) Write(b []byte) (int, error) {
+	p.written += int64(len(b))
+	if p.size > 0 {
+		fmt.Printf("\r%.2f%%", float64(p.written)/float64(p.size)*100)
+	} else {
+		fmt.Printf("\r%.2f MiB", float64(p.written)/1024/1024)
+	}
+	return len(b), nil
+}
+
+// returns path of .part file persisting partial download of release asset
+func partFilePath(downloadDir string, repo *Repo, release *Release) string {
+	name := strings.Join([]string{
+		strings.ReplaceAll(repo.Path, "/", "_"),
+		release.Version,
+		release.Name,
+	}, "_")
+	return filepath.Join(downloadDir, strings.ReplaceAll(name, "/", "_")+".part")
+}
+
+// parses total size from Content-Range header like "bytes 100-199/200",
+// returns -1 if unknown
+func totalFromContentRange(contentRange string) int64 {
+	_, total, found := strings.Cut(contentRange, "/")
+	if !found || total == "*" {
+		return -1
+	}
+	size, err := strconv.ParseInt(total, 10, 64)
+	if err != nil {
+		return -1
+	}
+	return size
+}
+
+// downloads asset into .part file, resuming from its current size
+// with HTTP Range request, returns true if failed attempt can be retried
+func downloadPart(link string, ghPat string, partPath string) (bool, error) {
+	var offset int64
+	fi, err := os.Stat(partPath)
+	if err == nil {
+		offset = fi.Size()
+	}
+	c, req, err := AuthedClient(link, ghPat)
+	if err != nil {
+		return false, err
+	}
+	if offset > 0 {
+		fmt.Printf("resuming download from %d bytes\n", offset)
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	resp, err := c.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+
+	size := int64(-1)
+	flags := os.O_CREATE | os.O_WRONLY
+	switch resp.StatusCode {
+	case http.StatusOK:
+		// server ignored Range, start over
+		offset = 0
+		flags |= os.O_TRUNC
+		size = resp.ContentLength
+	case http.StatusPartialContent:
+		if !strings.HasPrefix(resp.Header.Get("Content-Range"), fmt.Sprintf("bytes %d-", offset)) {
+			os.Remove(partPath)
+			return true, fmt.Errorf("unexpected Content-Range %s", resp.Header.Get("Content-Range"))
+		}
+		flags |= os.O_APPEND
+		size = totalFromContentRange(resp.Header.Get("Content-Range"))
+	case http.StatusRequestedRangeNotSatisfiable:
+		if totalFromContentRange(resp.Header.Get("Content-Range")) == offset {
+			// .part file is already complete
+			return false, nil
+		}
+		os.Remove(partPath)
+		return true, errors.New("partial download does not match asset, starting over")
+	default:
+		return false, fmt.Errorf("API returned status %v", resp.StatusCode)
+	}
+
+	f, err := os.OpenFile(partPath, flags, 0600)
+	if err != nil {
+		return false, err
+	}
+	progress := &progressWriter{size: size, written: offset}
+	_, err = io.Copy(f, io.TeeReader(resp.Body, progress))
+	fmt.Printf("\n")
+	if err != nil {
+		f.Close()
+		return true, err
+	}
+	err = f.Close()
+	if err != nil {
+		return false, err
+	}
+	if size >= 0 && progress.written != size {
+		return true, fmt.Errorf("expected %d bytes, got %d", size, progress.written)
+	}
+	return false, nil
+}
+
+// downloads a binary into .part file and puts it into store directory of release,
+// asset is streamed to disk so memory usage does not depend on its size,
+// interrupted downloads are resumed, also on next puff run
+func DownloadBinary(cfgDir string, repo *Repo, release *Release, ghPat string) (*Installed, error) {
+	fmt.Printf("downloading %s\n", release.Link)
+	downloadDir, err := MustCreateDownloadDir(cfgDir)
+	if err != nil {
+		return nil, err
+	}
+	partPath := partFilePath(downloadDir, repo, release)
+	for attempt := 1; ; attempt++ {
+		retry, err := downloadPart(release.Link, ghPat, partPath)
+		if err == nil {
+			break
+		}
+		if !retry || attempt == downloadAttempts {
+			return nil, err
+		}
+		fmt.Printf("download interrupted: %s, retrying (%d/%d)\n", err.Error(), attempt, downloadAttempts)
+	}
+	// downloaded content is not reused after unpacking, successful or not
+	defer os.Remove(partPath)
+	sum, err := VerifyChecksum(partPath, release, ghPat)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := VerifySignature(partPath, release, repo, ghPat)
+	if err != nil {
+		return nil, err
+	}
+	provenance, err := VerifyProvenance(cfgDir, partPath, release, repo, ghPat)
+	if err != nil {
+		return nil, err
+	}
+	binNames, err := BinNames(repo)
+	if err != nil {
+		return nil, err
+	}
+	versionDir, err := MustCreateVersionDir(cfgDir, repo.Path, release.Version)
+	if err != nil {
+		return nil, err
+	}
+	files, err := saveOrUnpack(
+		versionDir,
+		partPath,
+		binNames,
+		repo.AllBinaries,
+		release.Name,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &Installed{
+		Files:      files,
+		Sha256:     sum,
+		Signature:  signature,
+		Provenance: provenance,
+	}, nil
+}
+
+// max size of checksum and signature files fetched from release
+const smallAssetLimit = 1 << 20
+
+// downloads small release asset like checksum file into memory
+func DownloadSmallAsset(link string, ghPat string) ([]byte, error) {
+	c, req, err := AuthedClient(link, ghPat)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API returned status %v", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, smallAssetLimit+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > smallAssetLimit {
+		return nil, fmt.Errorf("%s is larger than %d bytes", link, smallAssetLimit)
+	}
+	return data, nil
+}
+
+// returns all assets from API for custom repo
+func GetLatestReleaseAssets(path string, ghPat string) (*GithubResponse, error) {
+	return GetReleaseAssets(path, "", ghPat)
+}
+
+// returns release with given tag from Github API, latest if tag is empty
+func GetReleaseAssets(path string, tag string, ghPat string) (*GithubResponse, error) {
+	RepoUrl, err := url.JoinPath("https://api.github.com", "repos", path, "releases/latest")
+	if tag != "" {
+		RepoUrl, err = url.JoinPath("https://api.github.com", "repos", path, "releases/tags", tag)
+	}
+	if err != nil {
+		return nil, err
+	}
 	c, req, err := AuthedClient(RepoUrl, ghPat)
 	if err != nil {
 		return nil, err
@@ -69,199 +346,300 @@ func GetLatestRelease(repo *Repo, ghPat string) (*Release, error) {
 		if err != nil {
 			return nil, err
 		}
-		release := &Release{
-			Version: releaseJson.Version,
-		}
-
-		validName := regexp.MustCompile(repo.Regexp)
-		for _, asset := range releaseJson.Assets {
-			if validName.MatchString(asset.Name) {
-				release.Link = asset.URL
-				return release, nil
-			}
-		}
-		return nil, errors.New("No regexp matching name found in release assets")
+		return &releaseJson, nil
+	} else if tag != "" {
+		return nil, fmt.Errorf("release %s not found in %s (status %d)", tag, path, resp.StatusCode)
 	} else {
 		return nil, err
 	}
 }
 
-// saves binary directly to bin or unpacks it if it's .tar.gz
-func saveOrUnpack(cfgDir string, bodyBytes []byte, binName string, assetName string) error {
-	savePath := filepath.Join(cfgDir, "bin", binName)
-	tempPath := savePath + ".tmp"
-	matched, err := regexp.MatchString(`\.tar\.gz$`, assetName)
-	if err != nil {
-		return err
-	}
-	matchedTgz, err := regexp.MatchString(`\.tgz$`, assetName)
-	if err != nil {
-		return err
+// number of releases requested per page from Github API
+const releasesPerPage = 100
+
+// how many pages of releases are searched for version matching constraint
+const releasePages = 3
+
+// returns releases of repo from Github API, newest first, drafts skipped,
+// at most limit releases are listed, 0 lists up to releasePages pages
+func ListReleases(path string, ghPat string, limit int) ([]GithubResponse, error) {
+	perPage := releasesPerPage
+	if limit > 0 && limit < perPage {
+		perPage = limit
 	}
-	if matched || matchedTgz {
-		// unpack .tar.gz
-		fmt.Printf("unpacking %s\n", assetName)
-		bodyReader := bytes.NewReader(bodyBytes)
-		zr, err := gzip.NewReader(bodyReader)
+	var releases []GithubResponse
+	for page := 1; page <= releasePages; page++ {
+		RepoUrl, err := url.JoinPath("https://api.github.com", "repos", path, "releases")
 		if err != nil {
-			return err
+			return nil, err
 		}
-		defer zr.Close()
-		degzippedBytes, err := io.ReadAll(zr)
+		RepoUrl += "?per_page=" + strconv.Itoa(perPage) + "&page=" + strconv.Itoa(page)
+		c, req, err := AuthedClient(RepoUrl, ghPat)
 		if err != nil {
-			return err
+			return nil, err
 		}
-		degzippedBody := bytes.NewReader(degzippedBytes)
-		tr := tar.NewReader(degzippedBody)
-		for {
-			hdr, err := tr.Next()
-			if err == io.EOF {
-				break // End of archive
-			}
-			if err != nil {
-				return err
-			}
-			var nameToCompare string
-			// handle possible paths in tarball
-			if strings.Contains(hdr.Name, "/") {
-				nameToCompare = strings.Split(hdr.Name, "/")[len(strings.Split(hdr.Name, "/"))-1]
-			} else {
-				nameToCompare = hdr.Name
-			}
-			if nameToCompare == binName {
-				// Write the file
-				binBytes, err := io.ReadAll(tr)
-				if err != nil {
-					return err
-				}
-				writePath := savePath
-				if binName == "puff" {
-					writePath = tempPath
-				}
-				fmt.Printf("writing %s to %s\n", binName, writePath)
-				err = os.WriteFile(writePath, binBytes, 0750)
-				if err != nil {
-					return err
-				}
-				if binName == "puff" {
-					err = os.Rename(tempPath, savePath)
-					if err != nil {
-						return err
-					}
-					fmt.Printf("replaced %s with new version\n", savePath)
-				}
-				return nil
-			}
+		resp, err := c.Do(req)
+		if err != nil {
+			return nil, err
 		}
-		return errors.New("binary not found in tar.gz archive")
-	} else {
-		// save directly
-		writePath := savePath
-		if binName == "puff" {
-			writePath = tempPath
+		bodyBytes, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("listing releases of %s: status %d", path, resp.StatusCode)
 		}
-		fmt.Printf("writing %s to %s\n", binName, writePath)
-		err := os.WriteFile(writePath, bodyBytes, 0750)
+		var pageReleases []GithubResponse
+		err = json.Unmarshal(bodyBytes, &pageReleases)
 		if err != nil {
-			return err
+			return nil, err
 		}
-		if binName == "puff" {
-			err = os.Rename(tempPath, savePath)
-			if err != nil {
-				return err
+		for _, release := range pageReleases {
+			if !release.Draft {
+				releases = append(releases, release)
 			}
-			fmt.Printf("replaced %s with new version\n", savePath)
+		}
+		if limit > 0 && len(releases) >= limit {
+			return releases[:limit], nil
+		}
+		if len(pageReleases) < perPage {
+			break
 		}
 	}
-	return nil
+	return releases, nil
 }
 
-// downloads a binary and puts into bin directory
-func DownloadBinary(cfgDir string, repo *Repo, release *Release, ghPat string) error {
-	fmt.Printf("downloading %s\n", release.Link)
-	c, req, err := AuthedClient(release.Link, ghPat)
-	if err != nil {
-		return err
-	}
-
-	resp, err := c.Do(req)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
+// release channels, any other channel is a regexp matching tag name
+const (
+	StableChannel     = "stable"
+	PrereleaseChannel = "prerelease"
+)
 
-	if resp.StatusCode == http.StatusOK {
-		rawSize := resp.Header.Get("Content-Length")
-		size, err := strconv.ParseInt(rawSize, 10, 64)
+// returns releases belonging to channel, stable releases only for empty one
+func channelReleases(releases []GithubResponse, channel string) ([]GithubResponse, error) {
+	var tagName *regexp.Regexp
+	if channel != "" && channel != StableChannel && channel != PrereleaseChannel {
+		var err error
+		tagName, err = regexp.Compile(channel)
 		if err != nil {
-			return err
+			return nil, fmt.Errorf("invalid channel regexp: %w", err)
 		}
-		bodyBytes := make([]byte, size)
-		offset := 0
-		buf := make([]byte, 65536)
-		for offset < int(size) {
-			percent := float64(offset) / float64(size) * 100
-			fmt.Printf("\r%.2f%%", percent)
-			n, err := resp.Body.Read(buf)
-			if err != nil && err != io.EOF {
-				return err
+	}
+	var matching []GithubResponse
+	for _, release := range releases {
+		switch {
+		case tagName != nil:
+			if !tagName.MatchString(release.Version) {
+				continue
 			}
-			if n == 0 {
-				break
+		case channel != PrereleaseChannel:
+			if release.Prerelease {
+				continue
 			}
-			copy(bodyBytes[offset:], buf[:n])
-			offset += n
 		}
-		if offset != int(size) {
-			return fmt.Errorf("expected %d bytes, got %d", size, offset)
+		matching = append(matching, release)
+	}
+	return matching, nil
+}
+
+// returns releases satisfying constraint,
+// prerelease part of versions is ignored if prereleases are allowed
+func allowedReleases(releases []GithubResponse, constraint *Constraint, prerelease bool) []GithubResponse {
+	var allowed []GithubResponse
+	for _, release := range releases {
+		v, ok := ParseVersion(release.Version)
+		if !ok {
+			continue
 		}
-		fmt.Printf("\n")
-		binName, err := BinNameFromPath(repo)
-		if err != nil {
-			return err
+		if prerelease {
+			v.Pre = ""
 		}
-		err = saveOrUnpack(
-			cfgDir,
-			bodyBytes,
-			binName,
-			strings.Split(release.Link, "/")[len(strings.Split(release.Link, "/"))-1],
-		)
-		if err != nil {
-			return err
+		if constraint.Allows(v) {
+			allowed = append(allowed, release)
 		}
-	} else {
-		return fmt.Errorf("API returned status %v", resp.StatusCode)
 	}
+	return allowed
+}
 
-	return nil
+// sorts releases by version, newest first, tags without version go last
+func sortBySemver(releases []GithubResponse) {
+	slices.SortStableFunc(releases, func(a, b GithubResponse) int {
+		aVer, aOk := ParseVersion(a.Version)
+		bVer, bOk := ParseVersion(b.Version)
+		switch {
+		case aOk && bOk:
+			return bVer.Compare(aVer)
+		case aOk:
+			return -1
+		case bOk:
+			return 1
+		}
+		return 0
+	})
 }
 
-// returns all assets from API for custom repo
-func GetLatestReleaseAssets(path string, ghPat string) (*GithubResponse, error) {
-	RepoUrl, err := url.JoinPath("https://api.github.com", "repos", path, "releases/latest")
-	c, req, err := AuthedClient(RepoUrl, ghPat)
+// orders of releases, by version or by publishing date
+const (
+	SemverOrder = "semver"
+	DateOrder   = "date"
+)
+
+// checks if releases of Repo must be listed instead of asking for latest one
+func needsReleaseList(repo *Repo) bool {
+	return repo.Constraint != "" || repo.Order == SemverOrder || repo.TagPrefix != "" ||
+		(repo.Channel != "" && repo.Channel != StableChannel)
+}
+
+// returns releases of Repo.Channel satisfying Repo.Constraint, newest first,
+// ordered by version if constraint is set or Repo.Order is semver,
+// by publishing date otherwise
+func RepoReleases(repo *Repo, ghPat string) ([]GithubResponse, error) {
+	releases, err := ListReleases(repo.Path, ghPat, 0)
 	if err != nil {
 		return nil, err
 	}
-
-	resp, err := c.Do(req)
+	releases = prefixedReleases(releases, repo.TagPrefix)
+	releases, err = channelReleases(releases, repo.Channel)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode == http.StatusOK {
-		bodyBytes, err := io.ReadAll(resp.Body)
+	if repo.Constraint != "" {
+		constraint, err := ParseConstraint(repo.Constraint)
 		if err != nil {
 			return nil, err
 		}
-		var releaseJson GithubResponse
-		err = json.Unmarshal(bodyBytes, &releaseJson)
-		if err != nil {
-			return nil, err
+		releases = allowedReleases(releases, constraint, repo.Channel != "" && repo.Channel != StableChannel)
+	}
+	if repo.Constraint != "" || repo.Order == SemverOrder {
+		sortBySemver(releases)
+	}
+	return releases, nil
+}
+
+// returns releases with tag starting with prefix, prefix is stripped
+// from their versions, all releases are returned for empty prefix
+func prefixedReleases(releases []GithubResponse, prefix string) []GithubResponse {
+	if prefix == "" {
+		return releases
+	}
+	var prefixed []GithubResponse
+	for _, release := range releases {
+		if version, found := strings.CutPrefix(release.Version, prefix); found {
+			release.Version = version
+			prefixed = append(prefixed, release)
 		}
-		return &releaseJson, nil
+	}
+	return prefixed
+}
+
+// returns full tag of pinned release, Repo.TagPrefix is added if missing
+func pinnedTag(repo *Repo) string {
+	if strings.HasPrefix(repo.Tag, repo.TagPrefix) {
+		return repo.Tag
+	}
+	return repo.TagPrefix + repo.Tag
+}
+
+// returns release of Repo to install: one with Repo.Tag if pinned,
+// otherwise newest release from RepoReleases or latest one,
+// Repo.TagPrefix is stripped from version
+func GetRepoRelease(repo *Repo, ghPat string) (*GithubResponse, error) {
+	if repo.Tag != "" {
+		release, err := GetReleaseAssets(repo.Path, pinnedTag(repo), ghPat)
+		if err != nil || release == nil {
+			return release, err
+		}
+		release.Version = strings.TrimPrefix(release.Version, repo.TagPrefix)
+		return release, nil
+	}
+	if !needsReleaseList(repo) {
+		return GetReleaseAssets(repo.Path, "", ghPat)
+	}
+	releases, err := RepoReleases(repo, ghPat)
+	if err != nil {
+		return nil, err
+	}
+	if len(releases) == 0 {
+		return nil, fmt.Errorf("no release of %s matches channel/constraint", repo.Path)
+	}
+	fmt.Printf("newest matching release: %s\n", releases[0].Version)
+	return &releases[0], nil
+}
+
+// how many releases are searched for matching asset by default
+const defaultWalkDepth = 10
+
+// returns newest release of Repo for which hasAsset is true,
+// walks back through at most Repo.Depth releases
+// when assets are missing in the newest one
+func WalkReleases(repo *Repo, ghPat string, hasAsset func(*GithubResponse) bool) (*GithubResponse, error) {
+	newest, err := GetRepoRelease(repo, ghPat)
+	if err != nil {
+		return nil, err
+	}
+	if newest == nil {
+		return nil, fmt.Errorf("no release found for %s", repo.Path)
+	}
+	if hasAsset(newest) {
+		return newest, nil
+	}
+	depth := repo.Depth
+	if depth == 0 {
+		depth = defaultWalkDepth
+	}
+	if repo.Tag != "" || depth < 2 {
+		return nil, fmt.Errorf("%w in %s release %s", ErrNoMatchingAsset, repo.Path, newest.Version)
+	}
+	fmt.Printf("no matching asset in %s, searching older releases\n", newest.Version)
+	var releases []GithubResponse
+	if needsReleaseList(repo) {
+		// filtered releases have to be listed in full
+		releases, err = RepoReleases(repo, ghPat)
 	} else {
+		releases, err = ListReleases(repo.Path, ghPat, depth)
+		if err == nil {
+			// listed releases include prereleases skipped by releases/latest
+			releases, err = channelReleases(prefixedReleases(releases, repo.TagPrefix), repo.Channel)
+		}
+	}
+	if err != nil {
 		return nil, err
 	}
+	for i := range releases[:min(depth, len(releases))] {
+		if releases[i].Version == newest.Version {
+			continue
+		}
+		if hasAsset(&releases[i]) {
+			fmt.Printf("using %s, newest release with matching asset\n", releases[i].Version)
+			releases[i].SkippedVersion = newest.Version
+			return &releases[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%w in last %d releases of %s", ErrNoMatchingAsset, depth, repo.Path)
+}
+
+// returns releases of Repo newer than from up to and including to, oldest first
+func ReleasesBetween(repo *Repo, from string, to string, ghPat string) ([]GithubResponse, error) {
+	releases, err := ListReleases(repo.Path, ghPat, 0)
+	if err != nil {
+		return nil, err
+	}
+	releases = prefixedReleases(releases, repo.TagPrefix)
+	var between []GithubResponse
+	for _, release := range releases {
+		afterFrom, isSemver := CompareTags(release.Version, from)
+		if !isSemver || afterFrom <= 0 {
+			continue
+		}
+		if beforeTo, _ := CompareTags(release.Version, to); beforeTo > 0 {
+			continue
+		}
+		between = append(between, release)
+	}
+	slices.SortFunc(between, func(a, b GithubResponse) int {
+		c, _ := CompareTags(a.Version, b.Version)
+		return c
+	})
+	return between, nil
 }
@@ -0,0 +1,111 @@
+package puff
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTotalFromContentRange(t *testing.T) {
+	tests := []struct {
+		header string
+		total  int64
+	}{
+		{"bytes 0-9/10", 10},
+		{"bytes 100-199/1234", 1234},
+		{"bytes */42", 42},
+		{"bytes 0-9/*", -1},
+		{"bytes 0-9/abc", -1},
+		{"", -1},
+	}
+	for _, tt := range tests {
+		if total := totalFromContentRange(tt.header); total != tt.total {
+			t.Errorf("totalFromContentRange(%q) = %d, want %d", tt.header, total, tt.total)
+		}
+	}
+}
+
+func TestDownloadPart(t *testing.T) {
+	content := []byte(strings.Repeat("0123456789", 100))
+	// serves content honoring Range requests, including 416 responses
+	ranged := func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "asset", time.Time{}, bytes.NewReader(content))
+	}
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		part    []byte
+		retry   bool
+		failed  bool
+		result  []byte
+	}{
+		{"fresh download", ranged, nil, false, false, content},
+		{"resumed download", ranged, content[:300], false, false, content},
+		{"already complete", ranged, content, false, false, content},
+		{
+			"part longer than asset", ranged, append(bytes.Clone(content), "junk"...),
+			true, true, nil,
+		},
+		{
+			"range ignored", func(w http.ResponseWriter, r *http.Request) {
+				w.Write(content)
+			},
+			[]byte("stale"), false, false, content,
+		},
+		{
+			"wrong range returned", func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Range", "bytes 0-999/1000")
+				w.WriteHeader(http.StatusPartialContent)
+				w.Write(content)
+			},
+			content[:300], true, true, nil,
+		},
+		{
+			"truncated body", func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Length", "1000")
+				w.Write(content[:100])
+			},
+			nil, true, true, content[:100],
+		},
+		{
+			"not found", func(w http.ResponseWriter, r *http.Request) {
+				http.NotFound(w, r)
+			},
+			nil, false, true, nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(tt.handler)
+			defer srv.Close()
+			partPath := filepath.Join(t.TempDir(), "asset.part")
+			if tt.part != nil {
+				if err := os.WriteFile(partPath, tt.part, 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			retry, err := downloadPart(srv.URL+"/asset", "", partPath)
+			if retry != tt.retry || (err != nil) != tt.failed {
+				t.Errorf("downloadPart = %v, %v, want retry %v and error %v", retry, err, tt.retry, tt.failed)
+			}
+			result, err := os.ReadFile(partPath)
+			if tt.result == nil {
+				if err == nil {
+					t.Errorf(".part file should be removed, has %d bytes", len(result))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(result, tt.result) {
+				t.Errorf(".part file has %d bytes, want %d", len(result), len(tt.result))
+			}
+		})
+	}
+}
@@ -1,3 +1,15 @@
 module github.com/pgulb/puff
 
 go 1.25.3
+
+require (
+	github.com/ProtonMail/go-crypto v1.5.2
+	github.com/klauspost/compress v1.20.1
+	github.com/ulikunitz/xz v0.5.17
+	golang.org/x/crypto v0.55.0
+)
+
+require (
+	github.com/cloudflare/circl v1.6.3 // indirect
+	golang.org/x/sys v0.47.0 // indirect
+)
@@ -13,16 +13,64 @@ var Version = "v0.7.0"
 
 // Repo holds info about repo that a binary can be installed from
 type Repo struct {
-	Path   string
-	Desc   string
+	Path string
+	Desc string
+	// asset name regexp, {os}, {arch} and {libc} placeholders
+	// are resolved for current Platform
 	Regexp string
+	// name of installed binary, defaults to repo name
+	BinName string
+	// additional names symlinked to the binary
+	Aliases []string
+	// binaries to install from archive, defaults to BinName
+	Binaries []string
+	// install every executable found in archive
+	AllBinaries bool
+	// pinned minisign, GPG or SSH public key verifying signatures
+	PublicKey string
+	// refuse to install without valid signature
+	RequireSignature bool
+	// release tag to install instead of latest
+	Tag string
+	// version range that updates must stay in, like ~1.4 or <2.0.0
+	Constraint string
+	// release channel: stable, prerelease or regexp matching tag name
+	Channel string
+	// how many releases are searched for matching asset
+	Depth int
+	// semver to order releases by version instead of publishing date
+	Order string
+	// only releases with tags starting with prefix are considered,
+	// for monorepos tagging components like kustomize/v5.4.0
+	TagPrefix string
 }
 
 // Metadata holds info about a single installed binary and its version
 type Metadata struct {
-	Path      string   `json:"path"`
-	Version   string   `json:"version"`
-	NameParts []string `json:"name_parts"`
+	Path             string   `json:"path"`
+	Version          string   `json:"version"`
+	NameParts        []string `json:"name_parts"`
+	Regexp           string   `json:"regexp,omitempty"`
+	Tag              string   `json:"tag,omitempty"`
+	Constraint       string   `json:"constraint,omitempty"`
+	Channel          string   `json:"channel,omitempty"`
+	Depth            int      `json:"depth,omitempty"`
+	Order            string   `json:"order,omitempty"`
+	TagPrefix        string   `json:"tag_prefix,omitempty"`
+	Held             bool     `json:"held,omitempty"`
+	Available        string   `json:"available,omitempty"`
+	Files            []string `json:"files,omitempty"`
+	BinName          string   `json:"bin_name,omitempty"`
+	Aliases          []string `json:"aliases,omitempty"`
+	Sha256           string   `json:"sha256,omitempty"`
+	PublicKey        string   `json:"public_key,omitempty"`
+	RequireSignature bool     `json:"require_signature,omitempty"`
+	Signature        string   `json:"signature,omitempty"`
+	Provenance       string   `json:"provenance,omitempty"`
+	Platform         string   `json:"platform,omitempty"`
+	AllBinaries      bool     `json:"all_binaries,omitempty"`
+	// previous versions kept for rollback, newest first
+	History []Metadata `json:"history,omitempty"`
 }
 
 // MetadataList is used to store all installed bins' metadata on disk
@@ -36,257 +84,268 @@ func AvailableRepos() *[]Repo {
 		{
 			Path:   "pgulb/plasma",
 			Desc:   "Docker container controller with own HTTP API",
-			Regexp: `\blinux-amd64\b`,
+			Regexp: `\b{os}-{arch}\b`,
 		},
 		{
 			Path:   "charmbracelet/glow",
 			Desc:   "Render markdown on the CLI, with pizzazz!",
-			Regexp: `Linux_x86_64\.tar\.gz$`,
+			Regexp: `{os}_{arch}\.tar\.gz$`,
 		},
 		{
 			Path:   "nektos/act",
 			Desc:   "Run your GitHub Actions locally",
-			Regexp: `^act_Linux_x86_64\.tar\.gz$`,
+			Regexp: `^act_{os}_{arch}\.tar\.gz$`,
 		},
 		{
 			Path:   "coreos/butane",
 			Desc:   "Butane translates human-readable Butane Configs into machine-readable Ignition Configs.",
-			Regexp: `^butane-x86_64-unknown-linux-gnu$`,
+			Regexp: `^butane-{arch}-unknown-linux-gnu$`,
 		},
 		{
-			Path:   "pkgforge-dev/ghostty-appimage",
-			Desc:   "AppImage for Ghostty Terminal Emulator",
-			Regexp: `x86_64\.AppImage$`,
+			Path:    "pkgforge-dev/ghostty-appimage",
+			Desc:    "AppImage for Ghostty Terminal Emulator",
+			Regexp:  `{arch}\.AppImage$`,
+			BinName: "ghostty",
 		},
 		{
 			Path:   "go-task/task",
 			Desc:   "A task runner / simpler Make alternative written in Go",
-			Regexp: `^task_linux_amd64\.tar\.gz$`,
+			Regexp: `^task_{os}_{arch}\.tar\.gz$`,
 		},
 		{
 			Path:   "eza-community/eza",
 			Desc:   "A modern alternative to ls",
-			Regexp: `^eza_x86_64-unknown-linux-musl\.tar\.gz$`,
+			Regexp: `^eza_{arch}-unknown-linux-{libc}\.tar\.gz$`,
 		},
 		{
 			Path:   "starship/starship",
 			Desc:   "The minimal, blazing-fast, and infinitely customizable prompt for any shell!",
-			Regexp: `^starship-x86_64-unknown-linux-musl\.tar\.gz$`,
+			Regexp: `^starship-{arch}-unknown-linux-musl\.tar\.gz$`,
 		},
 		{
 			Path:   "bootandy/dust",
 			Desc:   "A more intuitive version of du in rust",
-			Regexp: `^dust-v.*-x86_64-unknown-linux-musl\.tar\.gz$`,
+			Regexp: `^dust-v.*-{arch}-unknown-linux-musl\.tar\.gz$`,
 		},
 		{
 			Path:   "mikefarah/yq",
 			Desc:   "yq is a portable command-line YAML, JSON, XML, CSV, TOML, HCL and properties processor",
-			Regexp: `^yq_linux_amd64$`,
+			Regexp: `^yq_{os}_{arch}$`,
 		},
 		{
 			Path:   "jesseduffield/lazydocker",
 			Desc:   "The lazier way to manage everything docker",
-			Regexp: `^lazydocker_.*_Linux_x86_64\.tar\.gz$`,
+			Regexp: `^lazydocker_.*_{os}_{arch}\.tar\.gz$`,
 		},
 		{
 			Path:   "jesseduffield/lazygit",
 			Desc:   "simple terminal UI for git commands",
-			Regexp: `^lazygit_.*_linux_x86_64\.tar\.gz$`,
+			Regexp: `^lazygit_.*_{os}_{arch}\.tar\.gz$`,
 		},
 		{
-			Path:   "fastfetch-cli/fastfetch",
-			Desc:   "A maintained, feature-rich and performance oriented, neofetch like system information tool",
-			Regexp: `^fastfetch-linux-amd64\.tar\.gz$`,
+			Path:     "fastfetch-cli/fastfetch",
+			Desc:     "A maintained, feature-rich and performance oriented, neofetch like system information tool",
+			Regexp:   `^fastfetch-{os}-{arch}\.tar\.gz$`,
+			Binaries: []string{"fastfetch", "flashfetch"},
 		},
 		{
 			Path:   "sharkdp/fd",
 			Desc:   "Simple, fast and user-friendly alternative to find",
-			Regexp: `^fd-.*-x86_64-unknown-linux-musl\.tar\.gz$`,
+			Regexp: `^fd-.*-{arch}-unknown-linux-musl\.tar\.gz$`,
 		},
 		{
 			Path:   "stedolan/jq",
 			Desc:   "Lightweight and flexible command-line JSON processor",
-			Regexp: `^jq-linux64$`,
+			Regexp: `^jq-{os}-?{arch}$`,
 		},
 		{
-			Path:   "dbrgn/tealdeer",
-			Desc:   "A fast tldr client for simplified and community-driven man pages",
-			Regexp: `^tealdeer-linux-x86_64-musl$`,
+			Path:    "dbrgn/tealdeer",
+			Desc:    "A fast tldr client for simplified and community-driven man pages",
+			Regexp:  `^tealdeer-{os}-{arch}-musl$`,
+			BinName: "tldr",
 		},
 		{
 			Path:   "ducaale/xh",
 			Desc:   "Friendly and fast tool for sending HTTP requests",
-			Regexp: `^xh-.*-x86_64-unknown-linux-musl\.tar\.gz$`,
+			Regexp: `^xh-.*-{arch}-unknown-linux-musl\.tar\.gz$`,
 		},
 		{
 			Path:   "sharkdp/bat",
 			Desc:   "A cat clone with syntax highlighting and Git integration",
-			Regexp: `^bat-v.*-x86_64-unknown-linux-musl\.tar\.gz$`,
+			Regexp: `^bat-v.*-{arch}-unknown-linux-{libc}\.tar\.gz$`,
 		},
 		{
 			Path:   "Y2Z/monolith",
 			Desc:   "Save complete web pages as single HTML files",
-			Regexp: `^monolith-gnu-linux-x86_64$`,
+			Regexp: `^monolith-gnu-{os}-{arch}$`,
 		},
 		{
 			Path:   "topgrade-rs/topgrade",
 			Desc:   "Upgrade all tools on the system",
-			Regexp: `^topgrade-v\d+\.\d+\.\d+-x86_64-unknown-linux-musl\.tar\.gz$`,
+			Regexp: `^topgrade-v\d+\.\d+\.\d+-{arch}-unknown-linux-musl\.tar\.gz$`,
 		},
 		{
 			Path:   "fullstorydev/grpcurl",
 			Desc:   "A command-line tool for interacting with gRPC servers",
-			Regexp: `^grpcurl_.*_linux_x86_64\.tar\.gz$`,
+			Regexp: `^grpcurl_.*_{os}_{arch}\.tar\.gz$`,
 		},
 		{
 			Path:   "derailed/k9s",
 			Desc:   "Kubernetes CLI to manage your clusters in style",
-			Regexp: `^k9s_Linux_amd64\.tar\.gz$`,
+			Regexp: `^k9s_{os}_{arch}\.tar\.gz$`,
 		},
 		{
-			Path:   "astral-sh/uv",
-			Desc:   "An extremely fast Python package installer and resolver, written in Rust.",
-			Regexp: `^uv-x86_64-unknown-linux-gnu\.tar\.gz$`,
+			Path:     "astral-sh/uv",
+			Desc:     "An extremely fast Python package installer and resolver, written in Rust.",
+			Regexp:   `^uv-{arch}-unknown-linux-{libc}\.tar\.gz$`,
+			Binaries: []string{"uv", "uvx"},
 		},
 		{
 			Path:   "astral-sh/ruff",
 			Desc:   "An extremely fast Python linter and code formatter, written in Rust.",
-			Regexp: `^ruff-x86_64-unknown-linux-gnu\.tar\.gz$`,
+			Regexp: `^ruff-{arch}-unknown-linux-{libc}\.tar\.gz$`,
 		},
 		{
 			Path:   "astral-sh/ty",
 			Desc:   "Static type checker for Python",
-			Regexp: `^ty-x86_64-unknown-linux-gnu\.tar\.gz$`,
+			Regexp: `^ty-{arch}-unknown-linux-{libc}\.tar\.gz$`,
 		},
 		{
 			Path:   "junegunn/fzf",
 			Desc:   "A command-line fuzzy finder",
-			Regexp: `^fzf-.*-linux_amd64\.tar\.gz$`,
+			Regexp: `^fzf-.*-{os}_{arch}\.tar\.gz$`,
 		},
 		{
 			Path:   "dandavison/delta",
 			Desc:   "A syntax-highlighting pager for git, diff, and grep output",
-			Regexp: `^delta-.*-x86_64-unknown-linux-gnu\.tar\.gz$`,
+			Regexp: `^delta-.*-{arch}-unknown-linux-{libc}\.tar\.gz$`,
 		},
 		{
 			Path:   "theryangeary/choose",
 			Desc:   "A human-friendly and fast alternative to cut and (sometimes) awk",
-			Regexp: `^choose-x86_64-unknown-linux-gnu$`,
+			Regexp: `^choose-{arch}-unknown-linux-gnu$`,
 		},
 		{
 			Path:   "direnv/direnv",
 			Desc:   "Unclutter your .profile with an extensible shell environment manager",
-			Regexp: `^direnv\.linux-amd64$`,
+			Regexp: `^direnv\.{os}-{arch}$`,
 		},
 		{
 			Path:   "lsd-rs/lsd",
 			Desc:   "The next gen ls command",
-			Regexp: `^lsd-v.*-x86_64-unknown-linux-gnu\.tar\.gz$`,
+			Regexp: `^lsd-v.*-{arch}-unknown-linux-{libc}\.tar\.gz$`,
 		},
 		{
 			Path:   "zellij-org/zellij",
 			Desc:   "A terminal workspace with batteries included",
-			Regexp: `^zellij.*x86_64.*linux.*\.tar\.gz$`,
+			Regexp: `^zellij.*{arch}.*{os}.*\.tar\.gz$`,
 		},
 		{
 			Path:   "aquasecurity/trivy",
 			Desc:   "Scanner for vulnerabilities in container images, file systems, and Git repositories",
-			Regexp: `^trivy_.*_Linux-64bit\.tar\.gz$`,
+			Regexp: `^trivy_.*_{os}-{arch}\.tar\.gz$`,
 		},
 		{
-			Path:   "FiloSottile/age",
-			Desc:   "A simple, modern and secure file encryption tool",
-			Regexp: `linux-amd64\.tar\.gz$`,
+			Path:     "FiloSottile/age",
+			Desc:     "A simple, modern and secure file encryption tool",
+			Regexp:   `{os}-{arch}\.tar\.gz$`,
+			Binaries: []string{"age", "age-keygen"},
 		},
 		{
 			Path:   "kubernetes/kompose",
 			Desc:   "A tool to convert Docker Compose files to Kubernetes manifests",
-			Regexp: `^kompose-linux-amd64$`,
+			Regexp: `^kompose-{os}-{arch}$`,
 		},
 		{
 			Path:   "wagoodman/dive",
 			Desc:   "A tool for exploring each layer in a docker image",
-			Regexp: `dive_.*_linux_amd64\.tar\.gz`,
+			Regexp: `dive_.*_{os}_{arch}\.tar\.gz`,
 		},
 		{
 			Path:   "FairwindsOps/pluto",
 			Desc:   "Detect deprecated Kubernetes API versions",
-			Regexp: `pluto_.*_linux_amd64\.tar\.gz`,
+			Regexp: `pluto_.*_{os}_{arch}\.tar\.gz`,
 		},
 		{
 			Path:   "derailed/popeye",
 			Desc:   "A Kubernetes cluster resource sanitizer",
-			Regexp: `popeye_linux_amd64\.tar\.gz`,
+			Regexp: `popeye_{os}_{arch}\.tar\.gz`,
 		},
 		{
 			Path:   "Lifailon/lazyjournal",
 			Desc:   "A TUI for reading logs from journald, auditd, file system, Docker containers, Compose stacks, Podman and Kubernetes pods with support for output coloring and multiple filtering modes.",
-			Regexp: `^lazyjournal-.*-linux-amd64$`,
+			Regexp: `^lazyjournal-.*-{os}-{arch}$`,
 		},
 		{
 			Path:   "golangci/golangci-lint",
 			Desc:   "Fast linters Runner for Go, running multiple linters in parallel with caching and YAML config support.",
-			Regexp: `golangci-lint-.*-linux-amd64\.tar\.gz`,
+			Regexp: `golangci-lint-.*-{os}-{arch}\.tar\.gz`,
 		},
 		{
 			Path:   "cosmtrek/air",
 			Desc:   "Live reloading for Go applications, automatically rebuilding and restarting on file changes.",
-			Regexp: `air_.*_linux_amd64\.tar\.gz`,
+			Regexp: `air_.*_{os}_{arch}\.tar\.gz`,
 		},
 		{
 			Path:   "codesenberg/bombardier",
 			Desc:   "Fast cross-platform HTTP benchmarking tool written in Go.",
-			Regexp: `^bombardier-linux-amd64$`,
+			Regexp: `^bombardier-{os}-{arch}$`,
 		},
 		{
 			Path:   "razorblade23/PyCrucible",
 			Desc:   "A cross-platform builder and launcher for Python applications using UV.",
-			Regexp: `^pycrucible-x86_64-unknown-linux-gnu$`,
+			Regexp: `^pycrucible-{arch}-unknown-linux-gnu$`,
 		},
 		{
 			Path:   "jorgerojas26/lazysql",
 			Desc:   "Cross-platform TUI database management tool written in Go",
-			Regexp: `^lazysql_Linux_x86_64\.tar\.gz$`,
+			Regexp: `^lazysql_{os}_{arch}\.tar\.gz$`,
 		},
 		{
 			Path:   "pamburus/hl",
 			Desc:   "Fast and powerful log viewer and processor for JSON/logfmt logs",
-			Regexp: `^hl-linux-x86_64-gnu\.tar\.gz$`,
+			Regexp: `^hl-{os}-{arch}-{libc}\.tar\.gz$`,
 		},
 		{
 			Path:   "google/ko",
 			Desc:   "A tool to build and deploy Go applications on Kubernetes, written in Go, simplifying container image creation.",
-			Regexp: `^ko_.*_Linux_x86_64\.tar\.gz$`,
+			Regexp: `^ko_.*_{os}_{arch}\.tar\.gz$`,
 		},
 		{
 			Path:   "tilt-dev/tilt",
 			Desc:   "A tool for defining and managing local development environments for microservices on Kubernetes, written in Go.",
-			Regexp: `^tilt\..*\.linux\.x86_64\.tar\.gz$`,
+			Regexp: `^tilt\..*\.{os}\.{arch}\.tar\.gz$`,
 		},
 		{
 			Path:   "denisidoro/navi",
 			Desc:   "An interactive cheatsheet tool for the command-line.",
-			Regexp: `^navi-v.*-x86_64-unknown-linux-musl\.tar\.gz$`,
+			Regexp: `^navi-v.*-{arch}-unknown-linux-musl\.tar\.gz$`,
 		},
 		{
 			Path:   "homeport/dyff",
 			Desc:   "Diff tool for YAML files and JSON.",
-			Regexp: `^dyff_.*_linux_amd64\.tar\.gz$`,
+			Regexp: `^dyff_.*_{os}_{arch}\.tar\.gz$`,
 		},
 		{
 			Path:   "darksworm/argonaut",
 			Desc:   "Keyboard-first terminal UI for Argo CD. Browse apps, scope by clusters/namespaces/projects, stream live resource status, trigger syncs, inspect diffs, and roll back safely — all without leaving your terminal.",
-			Regexp: `^argonaut-.*-linux-amd64\.tar\.gz$`,
+			Regexp: `^argonaut-.*-{os}-{arch}\.tar\.gz$`,
 		},
 		{
 			Path:   "anomalyco/opencode",
 			Desc:   "The open source coding agent.",
-			Regexp: `^opencode-linux-x64\.tar\.gz$`,
+			Regexp: `^opencode-{os}-{arch}\.tar\.gz$`,
 		},
 		{
 			Path:   "nathanieltooley/gokemon",
 			Desc:   "A terminal-based Pokemon battle Simulator!",
-			Regexp: `^gokemon_Linux_x86_64\.tar\.gz$`,
+			Regexp: `^gokemon_{os}_{arch}\.tar\.gz$`,
+		},
+		{
+			Path:      "kubernetes-sigs/kustomize",
+			Desc:      "Customization of kubernetes YAML configurations.",
+			Regexp:    `^kustomize_v.*_{os}_{arch}\.tar\.gz$`,
+			TagPrefix: "kustomize/",
 		},
 	}
 }
@@ -331,11 +390,22 @@ func AddMetaIfNotExists(
 ) (bool, error) {
 	for i := range metadata.Metadata {
 		if metadata.Metadata[i].Path == repo.Path {
+			installed := metadata.Metadata[i].Version
 			// no action required
-			if metadata.Metadata[i].Version == release.Version {
+			if installed == release.Version && !republished(&metadata.Metadata[i], release) {
 				fmt.Println("no action required")
 				return false, nil
 			}
+			cmp, isSemver := CompareTags(installed, release.Version)
+			if isSemver && cmp == 0 {
+				fmt.Printf("%s is the same version as installed %s, no action required\n", release.Version, installed)
+				return false, nil
+			}
+			// pinned tag or changed constraint may require downgrade
+			if isSemver && cmp > 0 && repo.Tag == "" && installedAllowed(installed, repo.Constraint) {
+				fmt.Printf("installed version %s is newer than %s, no action required\n", installed, release.Version)
+				return false, nil
+			}
 			// update version in metadata entry
 			fmt.Println("new version found, updating metadata")
 			metadata.Metadata[i].Version = release.Version
@@ -345,13 +415,36 @@ func AddMetaIfNotExists(
 	// add new entry if not found
 	fmt.Println("adding new metadata")
 	metadata.Metadata = append(metadata.Metadata, Metadata{
-		Path:      repo.Path,
-		Version:   release.Version,
-		NameParts: nameParts,
+		Path:        repo.Path,
+		Version:     release.Version,
+		NameParts:   nameParts,
+		AllBinaries: repo.AllBinaries,
+		BinName:     repo.BinName,
+		Aliases:     repo.Aliases,
 	})
 	return true, nil
 }
 
+// checks if asset of installed release changed while tag stayed the same,
+// like nightly builds republished under one tag
+func republished(entry *Metadata, release *Release) bool {
+	digest, found := strings.CutPrefix(release.Digest, "sha256:")
+	if !found || entry.Sha256 == "" || strings.EqualFold(digest, entry.Sha256) {
+		return false
+	}
+	fmt.Printf("asset of %s was republished\n", release.Version)
+	return true
+}
+
+// checks if installed version satisfies constraint of repo
+func installedAllowed(installed string, constraint string) bool {
+	if constraint == "" {
+		return true
+	}
+	c, err := ParseConstraint(constraint)
+	return err == nil && c.AllowsTag(installed)
+}
+
 // extract binary name from repo path
 func BinNameFromPath(repo *Repo) (string, error) {
 	splitted := strings.Split(repo.Path, "/")
@@ -361,6 +454,44 @@ func BinNameFromPath(repo *Repo) (string, error) {
 	return splitted[len(splitted)-1], nil
 }
 
+// returns names of binaries to install from Repo
+func BinNames(repo *Repo) ([]string, error) {
+	if len(repo.Binaries) > 0 {
+		return repo.Binaries, nil
+	}
+	if repo.BinName != "" {
+		return []string{repo.BinName}, nil
+	}
+	binName, err := BinNameFromPath(repo)
+	if err != nil {
+		return nil, err
+	}
+	return []string{binName}, nil
+}
+
+// returns names of files installed into bin for metadata entry,
+// entries saved by older puff versions only have repo name
+func InstalledFiles(entry *Metadata) []string {
+	if len(entry.Files) > 0 {
+		return entry.Files
+	}
+	if entry.BinName != "" {
+		return []string{entry.BinName}
+	}
+	splitted := strings.Split(entry.Path, "/")
+	return []string{splitted[len(splitted)-1]}
+}
+
+// returns pointer to metadata entry for repo path or nil if not found
+func GetMetaEntry(metadata *MetadataList, path string) *Metadata {
+	for i := range metadata.Metadata {
+		if metadata.Metadata[i].Path == path {
+			return &metadata.Metadata[i]
+		}
+	}
+	return nil
+}
+
 // ask user for name parts to search in binary name in release
 func PromptForNameParts() []string {
 	fmt.Println("Provide string to search in binary name to pick:")
@@ -378,6 +509,41 @@ func PromptForNameParts() []string {
 	return NameParts
 }
 
+// returns featured repo with given path or nil if there is none
+func GetFeaturedRepo(path string) *Repo {
+	for _, repo := range *AvailableRepos() {
+		if repo.Path == path {
+			return &repo
+		}
+	}
+	return nil
+}
+
+// checks if repo is one of featured repos
+func IsFeaturedRepo(path string) bool {
+	return GetFeaturedRepo(path) != nil
+}
+
+// returns tag prefix of repo to save in metadata,
+// empty for featured repos using their built in prefix
+func savedTagPrefix(repo *Repo) string {
+	featured := GetFeaturedRepo(repo.Path)
+	if featured != nil && featured.TagPrefix == repo.TagPrefix {
+		return ""
+	}
+	return repo.TagPrefix
+}
+
+// returns asset regexp of repo to save in metadata,
+// empty for featured repos using their built in regexp
+func savedRegexp(repo *Repo) string {
+	featured := GetFeaturedRepo(repo.Path)
+	if featured != nil && featured.Regexp == repo.Regexp {
+		return ""
+	}
+	return repo.Regexp
+}
+
 // searches for custom repo in metadata
 func IsCustomRepoAdded(metadata *MetadataList, path string) Metadata {
 	for _, v := range metadata.Metadata {
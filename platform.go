@@ -0,0 +1,86 @@
+package puff
+
+import (
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Platform describes host that binaries are installed for
+type Platform struct {
+	OS   string
+	Arch string
+	// gnu or musl on linux, empty elsewhere
+	Libc string
+}
+
+// names used in release assets for each GOARCH,
+// x86 is left out as regexps like .*{arch}.* would match it in x86_64
+var archAliases = map[string][]string{
+	"amd64":   {"amd64", "x86_64", "x64", "64bit"},
+	"arm64":   {"arm64", "aarch64"},
+	"386":     {"386", "i386", "i686", "32bit"},
+	"arm":     {"armv7", "armv6", "armhf", "arm"},
+	"ppc64le": {"ppc64le"},
+	"s390x":   {"s390x"},
+	"riscv64": {"riscv64"},
+	"loong64": {"loong64", "loongarch64"},
+}
+
+// names used in release assets for each GOOS
+var osAliases = map[string][]string{
+	"linux":  {"linux"},
+	"darwin": {"darwin", "macos", "apple-darwin", "osx"},
+}
+
+// detects libc of the host, musl is recognized by its dynamic loader
+func detectLibc() string {
+	if runtime.GOOS != "linux" {
+		return ""
+	}
+	matches, err := filepath.Glob("/lib/ld-musl-*.so.1")
+	if err == nil && len(matches) > 0 {
+		return "musl"
+	}
+	return "gnu"
+}
+
+// returns platform puff is running on
+func CurrentPlatform() Platform {
+	return Platform{
+		OS:   runtime.GOOS,
+		Arch: runtime.GOARCH,
+		Libc: detectLibc(),
+	}
+}
+
+// returns platform as os/arch/libc, as stored in metadata
+func (p Platform) String() string {
+	if p.Libc == "" {
+		return p.OS + "/" + p.Arch
+	}
+	return p.OS + "/" + p.Arch + "/" + p.Libc
+}
+
+// returns case insensitive regexp group matching any of names
+func aliasGroup(names []string) string {
+	return "(?i:" + strings.Join(names, "|") + ")"
+}
+
+// replaces {os}, {arch} and {libc} placeholders in asset regexp
+// with names matching the platform
+func ResolveRegexp(pattern string, p Platform) string {
+	osNames, found := osAliases[p.OS]
+	if !found {
+		osNames = []string{p.OS}
+	}
+	archNames, found := archAliases[p.Arch]
+	if !found {
+		archNames = []string{p.Arch}
+	}
+	return strings.NewReplacer(
+		"{os}", aliasGroup(osNames),
+		"{arch}", aliasGroup(archNames),
+		"{libc}", p.Libc,
+	).Replace(pattern)
+}
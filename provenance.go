@@ -0,0 +1,696 @@
+package puff
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// suffixes of Sigstore bundles published next to assets
+var sigstoreBundleSuffixes = []string{".sigstore.json", ".sigstore", ".bundle"}
+
+// suffixes of certificates published next to cosign .sig files
+var cosignCertSuffixes = []string{".pem", ".crt", ".cert"}
+
+// OIDC issuer of certificates for GitHub Actions workflows
+const githubActionsIssuer = "https://token.actions.githubusercontent.com"
+
+// Fulcio certificate extensions identifying the workflow,
+// v1 issuer holds raw string, newer extensions hold DER UTF8String
+var (
+	oidIssuerV1            = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 1}
+	oidIssuerV2            = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 8}
+	oidSourceRepositoryURI = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 12}
+)
+
+// cosign bundle format used before Sigstore protobuf bundles
+type legacyCosignBundle struct {
+	Base64Signature string `json:"base64Signature"`
+	Cert            string `json:"cert"`
+}
+
+// DSSE envelope, inside Sigstore bundles or on its own signed with
+// Fulcio certificate as provenance format of slsa-github-generator v1
+type dsseEnvelope struct {
+	PayloadType string `json:"payloadType"`
+	Payload     string `json:"payload"`
+	Signatures  []struct {
+		Sig  string `json:"sig"`
+		Cert string `json:"cert"`
+	} `json:"signatures"`
+}
+
+// subjects of in-toto statement
+type intotoStatement struct {
+	Subject []struct {
+		Digest map[string]string `json:"digest"`
+	} `json:"subject"`
+}
+
+// period in which key or CA of trust root was used
+type validFor struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// checks if t is inside period, missing end means it is still used
+func (v validFor) contains(t time.Time) bool {
+	return !t.Before(v.Start) && (v.End.IsZero() || !t.After(v.End))
+}
+
+// DER encoded certificate of trust root or bundle
+type rawCert struct {
+	RawBytes []byte `json:"rawBytes"`
+}
+
+// TrustedRoot holds Fulcio CAs and Rekor keys from trusted_root.json
+type TrustedRoot struct {
+	Tlogs []struct {
+		PublicKey struct {
+			RawBytes []byte   `json:"rawBytes"`
+			ValidFor validFor `json:"validFor"`
+		} `json:"publicKey"`
+		LogID struct {
+			KeyID []byte `json:"keyId"`
+		} `json:"logId"`
+	} `json:"tlogs"`
+	CertificateAuthorities []struct {
+		CertChain struct {
+			Certificates []rawCert `json:"certificates"`
+		} `json:"certChain"`
+		ValidFor validFor `json:"validFor"`
+	} `json:"certificateAuthorities"`
+}
+
+// Rekor transparency log entry of Sigstore bundle
+type tlogEntry struct {
+	LogIndex int64 `json:"logIndex,string"`
+	LogID    struct {
+		KeyID []byte `json:"keyId"`
+	} `json:"logId"`
+	IntegratedTime   int64 `json:"integratedTime,string"`
+	InclusionPromise *struct {
+		SignedEntryTimestamp []byte `json:"signedEntryTimestamp"`
+	} `json:"inclusionPromise"`
+	CanonicalizedBody []byte `json:"canonicalizedBody"`
+}
+
+// Sigstore bundle, signing certificate is a chain before v0.3
+type sigstoreBundle struct {
+	MediaType            string `json:"mediaType"`
+	VerificationMaterial struct {
+		X509CertificateChain struct {
+			Certificates []rawCert `json:"certificates"`
+		} `json:"x509CertificateChain"`
+		Certificate *rawCert    `json:"certificate"`
+		TlogEntries []tlogEntry `json:"tlogEntries"`
+	} `json:"verificationMaterial"`
+	MessageSignature *struct {
+		MessageDigest struct {
+			Digest []byte `json:"digest"`
+		} `json:"messageDigest"`
+		Signature []byte `json:"signature"`
+	} `json:"messageSignature"`
+	DsseEnvelope *dsseEnvelope `json:"dsseEnvelope"`
+}
+
+// fields of Rekor entry body holding signature,
+// for hashedrekord, dsse and intoto entries
+type rekorBody struct {
+	Kind string `json:"kind"`
+	Spec struct {
+		Signature struct {
+			Content []byte `json:"content"`
+		} `json:"signature"`
+		Signatures []struct {
+			Signature []byte `json:"signature"`
+		} `json:"signatures"`
+		Content struct {
+			Envelope struct {
+				Signatures []struct {
+					Sig []byte `json:"sig"`
+				} `json:"signatures"`
+			} `json:"envelope"`
+		} `json:"content"`
+	} `json:"spec"`
+}
+
+// reads trust root from trusted_root.json in config dir,
+// returns nil if user did not configure one
+func GetTrustedRoot(cfgDir string) (*TrustedRoot, error) {
+	data, err := os.ReadFile(filepath.Join(cfgDir, "trusted_root.json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	trustedRoot := &TrustedRoot{}
+	err = json.Unmarshal(data, trustedRoot)
+	if err != nil {
+		return nil, fmt.Errorf("trusted_root.json: %w", err)
+	}
+	return trustedRoot, nil
+}
+
+// returns expected source repository URI of certificates for Repo
+func sourceRepositoryURI(repo *Repo) string {
+	return "https://github.com/" + repo.Path
+}
+
+// returns value of Fulcio certificate extension or empty string
+func certExtension(cert *x509.Certificate, oid asn1.ObjectIdentifier) string {
+	for _, ext := range cert.Extensions {
+		if !ext.Id.Equal(oid) {
+			continue
+		}
+		if oid.Equal(oidIssuerV1) {
+			return string(ext.Value)
+		}
+		var value string
+		if _, err := asn1.Unmarshal(ext.Value, &value); err != nil {
+			return ""
+		}
+		return value
+	}
+	return ""
+}
+
+// checks that certificate was issued to GitHub Actions workflow of Repo
+func checkCertIdentity(cert *x509.Certificate, repo *Repo) error {
+	issuer := certExtension(cert, oidIssuerV2)
+	if issuer == "" {
+		issuer = certExtension(cert, oidIssuerV1)
+	}
+	if issuer != githubActionsIssuer {
+		return fmt.Errorf("certificate issued by %s, expected %s", issuer, githubActionsIssuer)
+	}
+	source := certExtension(cert, oidSourceRepositoryURI)
+	if !strings.EqualFold(source, sourceRepositoryURI(repo)) {
+		return fmt.Errorf("certificate issued for %s, expected %s", source, sourceRepositoryURI(repo))
+	}
+	return nil
+}
+
+// verifies certificate against CA chain, last certificate of chain is the root
+func verifyCertChain(cert *x509.Certificate, chain []rawCert, at time.Time) error {
+	if len(chain) == 0 {
+		return errors.New("empty CA chain")
+	}
+	roots := x509.NewCertPool()
+	intermediates := x509.NewCertPool()
+	for i, raw := range chain {
+		ca, err := x509.ParseCertificate(raw.RawBytes)
+		if err != nil {
+			return err
+		}
+		if i == len(chain)-1 {
+			roots.AddCert(ca)
+		} else {
+			intermediates.AddCert(ca)
+		}
+	}
+	_, err := cert.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   at,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+	})
+	return err
+}
+
+// checks certificate chain against Fulcio CAs from trust root at given time,
+// returns public key of certificate issued to GitHub Actions workflow of Repo
+func checkFulcioCert(trustedRoot *TrustedRoot, cert *x509.Certificate, at time.Time, repo *Repo) (*ecdsa.PublicKey, error) {
+	var chainErr error = errors.New("no Fulcio CA in trust root")
+	for _, ca := range trustedRoot.CertificateAuthorities {
+		if !ca.ValidFor.contains(at) {
+			continue
+		}
+		chainErr = verifyCertChain(cert, ca.CertChain.Certificates, at)
+		if chainErr == nil {
+			break
+		}
+	}
+	if chainErr != nil {
+		return nil, fmt.Errorf("certificate chain: %w", chainErr)
+	}
+	err := checkCertIdentity(cert, repo)
+	if err != nil {
+		return nil, err
+	}
+	pub, ok := cert.PublicKey.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, errors.New("unsupported certificate key type")
+	}
+	return pub, nil
+}
+
+// checks if Rekor entry body records given signature
+func entryHasSignature(body []byte, sig []byte) bool {
+	var entry rekorBody
+	if json.Unmarshal(body, &entry) != nil {
+		return false
+	}
+	switch entry.Kind {
+	case "hashedrekord":
+		return bytes.Equal(entry.Spec.Signature.Content, sig)
+	case "dsse":
+		for _, s := range entry.Spec.Signatures {
+			if bytes.Equal(s.Signature, sig) {
+				return true
+			}
+		}
+	case "intoto":
+		// envelope signature is base64 encoded once more in intoto entries
+		for _, s := range entry.Spec.Content.Envelope.Signatures {
+			if string(s.Sig) == base64.StdEncoding.EncodeToString(sig) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// verifies signed entry timestamp of Rekor entry with log key from trust root,
+// returns time the entry was integrated into the log
+func verifyTlogEntry(trustedRoot *TrustedRoot, entry *tlogEntry) (time.Time, error) {
+	if entry.InclusionPromise == nil {
+		return time.Time{}, errors.New("transparency log entry has no signed entry timestamp")
+	}
+	at := time.Unix(entry.IntegratedTime, 0)
+	// signed payload is canonical JSON, keys are in sorted order
+	payload, err := json.Marshal(struct {
+		Body           string `json:"body"`
+		IntegratedTime int64  `json:"integratedTime"`
+		LogID          string `json:"logID"`
+		LogIndex       int64  `json:"logIndex"`
+	}{
+		base64.StdEncoding.EncodeToString(entry.CanonicalizedBody),
+		entry.IntegratedTime,
+		hex.EncodeToString(entry.LogID.KeyID),
+		entry.LogIndex,
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+	hash := sha256.Sum256(payload)
+	for _, tlog := range trustedRoot.Tlogs {
+		if !bytes.Equal(tlog.LogID.KeyID, entry.LogID.KeyID) {
+			continue
+		}
+		if !tlog.PublicKey.ValidFor.contains(at) {
+			return time.Time{}, errors.New("transparency log key is not valid at integration time")
+		}
+		key, err := x509.ParsePKIXPublicKey(tlog.PublicKey.RawBytes)
+		if err != nil {
+			return time.Time{}, err
+		}
+		pub, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return time.Time{}, errors.New("unsupported transparency log key type")
+		}
+		if !ecdsa.VerifyASN1(pub, hash[:], entry.InclusionPromise.SignedEntryTimestamp) {
+			return time.Time{}, errors.New("invalid signed entry timestamp")
+		}
+		return at, nil
+	}
+	return time.Time{}, errors.New("transparency log of entry not in trust root")
+}
+
+// returns DER of bundle signing certificate
+func (b *sigstoreBundle) certificate() []byte {
+	if b.VerificationMaterial.Certificate != nil {
+		return b.VerificationMaterial.Certificate.RawBytes
+	}
+	if certs := b.VerificationMaterial.X509CertificateChain.Certificates; len(certs) > 0 {
+		return certs[0].RawBytes
+	}
+	return nil
+}
+
+// checks transparency log entry recording signature of bundle
+// and certificate at the time of the entry,
+// returns public key of certificate issued to GitHub Actions workflow of Repo
+func verifyBundleCert(trustedRoot *TrustedRoot, b *sigstoreBundle, sig []byte, repo *Repo) (*ecdsa.PublicKey, error) {
+	certData := b.certificate()
+	if certData == nil {
+		return nil, errors.New("bundle is not signed with certificate")
+	}
+	cert, err := x509.ParseCertificate(certData)
+	if err != nil {
+		return nil, err
+	}
+	err = errors.New("bundle has no transparency log entry")
+	for i := range b.VerificationMaterial.TlogEntries {
+		entry := &b.VerificationMaterial.TlogEntries[i]
+		if !entryHasSignature(entry.CanonicalizedBody, sig) {
+			err = errors.New("transparency log entry does not record bundle signature")
+			continue
+		}
+		var at time.Time
+		at, err = verifyTlogEntry(trustedRoot, entry)
+		if err != nil {
+			continue
+		}
+		return checkFulcioCert(trustedRoot, cert, at, repo)
+	}
+	return nil, err
+}
+
+// parses Sigstore bundle, returns error for other JSON documents
+func parseSigstoreBundle(data []byte) (*sigstoreBundle, error) {
+	b := &sigstoreBundle{}
+	err := json.Unmarshal(data, b)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(b.MediaType, "application/vnd.dev.sigstore.bundle") {
+		return nil, errors.New("not a Sigstore bundle")
+	}
+	return b, nil
+}
+
+// verifies Sigstore bundle offline against trust root,
+// artifact digest is checked against message signature or in-toto subject
+func verifySigstoreBundle(trustedRoot *TrustedRoot, b *sigstoreBundle, digest []byte, repo *Repo) error {
+	if b.MessageSignature != nil {
+		sig := b.MessageSignature.Signature
+		signed := b.MessageSignature.MessageDigest.Digest
+		if len(signed) > 0 && !bytes.Equal(signed, digest) {
+			return errors.New("bundle signs different artifact")
+		}
+		pub, err := verifyBundleCert(trustedRoot, b, sig, repo)
+		if err != nil {
+			return err
+		}
+		if !ecdsa.VerifyASN1(pub, digest, sig) {
+			return errors.New("invalid bundle signature")
+		}
+		return nil
+	}
+	if b.DsseEnvelope == nil {
+		return errors.New("bundle has neither message signature nor DSSE envelope")
+	}
+	payload, err := base64.StdEncoding.DecodeString(b.DsseEnvelope.Payload)
+	if err != nil {
+		return err
+	}
+	if !statementHasSubject(payload, hex.EncodeToString(digest)) {
+		return errors.New("asset is not a subject of bundle statement")
+	}
+	if len(b.DsseEnvelope.Signatures) != 1 {
+		return errors.New("bundle envelope must have exactly one signature")
+	}
+	sig, err := base64.StdEncoding.DecodeString(b.DsseEnvelope.Signatures[0].Sig)
+	if err != nil {
+		return err
+	}
+	pub, err := verifyBundleCert(trustedRoot, b, sig, repo)
+	if err != nil {
+		return err
+	}
+	if !verifyDsseSignature(pub, b.DsseEnvelope.PayloadType, payload, sig) {
+		return errors.New("invalid DSSE envelope signature")
+	}
+	return nil
+}
+
+// decodes PEM certificate, cosign writes it base64 encoded
+func parseCosignCert(data []byte) (*x509.Certificate, error) {
+	if !bytes.Contains(data, []byte("-----BEGIN")) {
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+		if err != nil {
+			return nil, err
+		}
+		data = decoded
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM certificate found")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+// checks certificate against Fulcio CAs from trust root
+// at the time certificate was issued, as there is no transparency log entry,
+// returns public key of certificate issued to GitHub Actions workflow of Repo
+func verifyFulcioCert(trustedRoot *TrustedRoot, certData []byte, repo *Repo) (*ecdsa.PublicKey, error) {
+	cert, err := parseCosignCert(certData)
+	if err != nil {
+		return nil, err
+	}
+	return checkFulcioCert(trustedRoot, cert, cert.NotBefore, repo)
+}
+
+// verifies cosign signature with certificate offline
+func verifyCosignSignature(
+	trustedRoot *TrustedRoot,
+	certData []byte,
+	sigData []byte,
+	digest []byte,
+	repo *Repo,
+) error {
+	pub, err := verifyFulcioCert(trustedRoot, certData, repo)
+	if err != nil {
+		return err
+	}
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(sigData)))
+	if err != nil {
+		return err
+	}
+	if !ecdsa.VerifyASN1(pub, digest, sig) {
+		return errors.New("invalid cosign signature")
+	}
+	return nil
+}
+
+// verifies bundle published next to asset, Sigstore or legacy cosign format
+func verifyBundleAsset(trustedRoot *TrustedRoot, data []byte, digest []byte, repo *Repo) error {
+	b, err := parseSigstoreBundle(data)
+	if err == nil {
+		return verifySigstoreBundle(trustedRoot, b, digest, repo)
+	}
+	var legacy legacyCosignBundle
+	if json.Unmarshal(data, &legacy) != nil || legacy.Cert == "" {
+		return fmt.Errorf("unsupported bundle format: %w", err)
+	}
+	return verifyCosignSignature(trustedRoot, []byte(legacy.Cert), []byte(legacy.Base64Signature), digest, repo)
+}
+
+// checks if in-toto bundle has subject with given sha256
+func bundleHasSubject(b *sigstoreBundle, hexDigest string) bool {
+	if b.DsseEnvelope == nil {
+		return false
+	}
+	payload, err := base64.StdEncoding.DecodeString(b.DsseEnvelope.Payload)
+	if err != nil {
+		return false
+	}
+	return statementHasSubject(payload, hexDigest)
+}
+
+// checks if in-toto statement has subject with given sha256
+func statementHasSubject(payload []byte, hexDigest string) bool {
+	var statement intotoStatement
+	if json.Unmarshal(payload, &statement) != nil {
+		return false
+	}
+	for _, subject := range statement.Subject {
+		if strings.EqualFold(subject.Digest["sha256"], hexDigest) {
+			return true
+		}
+	}
+	return false
+}
+
+// returns DSSE pre-authentication encoding of payload, the signed message
+func dssePAE(payloadType string, payload []byte) []byte {
+	return fmt.Appendf(nil, "DSSEv1 %d %s %d %s", len(payloadType), payloadType, len(payload), payload)
+}
+
+// checks ECDSA signature of DSSE envelope payload
+func verifyDsseSignature(pub *ecdsa.PublicKey, payloadType string, payload []byte, sig []byte) bool {
+	message := sha256.Sum256(dssePAE(payloadType, payload))
+	return ecdsa.VerifyASN1(pub, message[:], sig)
+}
+
+// verifies DSSE envelope offline, any signature made with
+// Fulcio certificate of GitHub Actions workflow of Repo is accepted
+func verifyDsseEnvelope(trustedRoot *TrustedRoot, envelope *dsseEnvelope, payload []byte, repo *Repo) error {
+	err := errors.New("envelope has no signature with certificate")
+	for _, s := range envelope.Signatures {
+		if s.Cert == "" {
+			continue
+		}
+		var pub *ecdsa.PublicKey
+		pub, err = verifyFulcioCert(trustedRoot, []byte(s.Cert), repo)
+		if err != nil {
+			continue
+		}
+		var sig []byte
+		sig, err = base64.StdEncoding.DecodeString(s.Sig)
+		if err != nil {
+			continue
+		}
+		if verifyDsseSignature(pub, envelope.PayloadType, payload, sig) {
+			return nil
+		}
+		err = errors.New("invalid DSSE envelope signature")
+	}
+	return err
+}
+
+// verifies provenance from .intoto.jsonl file holding one Sigstore bundle
+// or DSSE envelope per line, lines in other formats are reported and skipped,
+// returns false if nothing has the asset as subject
+func verifyIntotoAsset(trustedRoot *TrustedRoot, name string, data []byte, hexDigest string, repo *Repo) (bool, error) {
+	digest, err := hex.DecodeString(hexDigest)
+	if err != nil {
+		return false, err
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, smallAssetLimit)
+	unparsed := 0
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		if b, err := parseSigstoreBundle(line); err == nil {
+			if bundleHasSubject(b, hexDigest) {
+				return true, verifySigstoreBundle(trustedRoot, b, digest, repo)
+			}
+			continue
+		}
+		var envelope dsseEnvelope
+		if json.Unmarshal(line, &envelope) != nil || envelope.Payload == "" {
+			unparsed++
+			continue
+		}
+		payload, err := base64.StdEncoding.DecodeString(envelope.Payload)
+		if err != nil {
+			unparsed++
+			continue
+		}
+		if statementHasSubject(payload, hexDigest) {
+			return true, verifyDsseEnvelope(trustedRoot, &envelope, payload, repo)
+		}
+	}
+	if unparsed > 0 {
+		fmt.Printf("WARNING: %d line(s) of %s are neither Sigstore bundles nor DSSE envelopes, skipped\n", unparsed, name)
+	}
+	return false, scanner.Err()
+}
+
+// verifies Sigstore bundles, cosign signatures and SLSA provenance
+// published next to asset offline against trusted_root.json,
+// identity of signer must be GitHub Actions workflow of the Repo,
+// returns description of verification result or empty string if none published
+func VerifyProvenance(cfgDir string, assetPath string, release *Release, repo *Repo, ghPat string) (string, error) {
+	assetName := release.Name
+	var bundles, provenance []Asset
+	var cosignSig, cosignCert *Asset
+	for _, asset := range release.Assets {
+		for _, suffix := range sigstoreBundleSuffixes {
+			if asset.Name == assetName+suffix {
+				bundles = append(bundles, asset)
+			}
+		}
+		for _, suffix := range cosignCertSuffixes {
+			if asset.Name == assetName+suffix {
+				cosignCert = &asset
+			}
+		}
+		if asset.Name == assetName+".sig" {
+			cosignSig = &asset
+		}
+		if strings.HasSuffix(asset.Name, ".intoto.jsonl") {
+			provenance = append(provenance, asset)
+		}
+	}
+	if len(bundles) == 0 && len(provenance) == 0 && (cosignSig == nil || cosignCert == nil) {
+		return "", nil
+	}
+	trustedRoot, err := GetTrustedRoot(cfgDir)
+	if err != nil {
+		return "", err
+	}
+	if trustedRoot == nil {
+		fmt.Printf("sigstore material published for %s but no trusted_root.json in %s, skipping verification\n", assetName, cfgDir)
+		return "unverified (no trust root)", nil
+	}
+	f, err := os.Open(assetPath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	_, err = io.Copy(h, f)
+	if err != nil {
+		return "", err
+	}
+	digest := h.Sum(nil)
+
+	var verified []string
+	for _, asset := range bundles {
+		data, err := DownloadSmallAsset(asset.URL, ghPat)
+		if err != nil {
+			return "", err
+		}
+		err = verifyBundleAsset(trustedRoot, data, digest, repo)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", asset.Name, err)
+		}
+		verified = append(verified, "sigstore:"+asset.Name)
+	}
+	if len(bundles) == 0 && cosignSig != nil && cosignCert != nil {
+		sigData, err := DownloadSmallAsset(cosignSig.URL, ghPat)
+		if err != nil {
+			return "", err
+		}
+		certData, err := DownloadSmallAsset(cosignCert.URL, ghPat)
+		if err != nil {
+			return "", err
+		}
+		err = verifyCosignSignature(trustedRoot, certData, sigData, digest, repo)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", cosignSig.Name, err)
+		}
+		verified = append(verified, "cosign:"+cosignSig.Name)
+	}
+	for _, asset := range provenance {
+		data, err := DownloadSmallAsset(asset.URL, ghPat)
+		if err != nil {
+			return "", err
+		}
+		found, err := verifyIntotoAsset(trustedRoot, asset.Name, data, hex.EncodeToString(digest), repo)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", asset.Name, err)
+		}
+		if found {
+			verified = append(verified, "slsa:"+asset.Name)
+		}
+	}
+	if len(verified) == 0 {
+		fmt.Printf("no provenance found for %s\n", assetName)
+		return "", nil
+	}
+	result := "verified " + strings.Join(verified, ", ")
+	fmt.Printf("%s %s from %s\n", assetName, result, sourceRepositoryURI(repo))
+	return result, nil
+}
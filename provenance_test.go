@@ -0,0 +1,175 @@
+package puff
+
+import (
+	"bytes"
+	"encoding/base64"
+	"os"
+	"strings"
+	"testing"
+)
+
+// testdata holds Sigstore public good trust root and npm provenance bundle
+// of sigstore-js 2.0.0 built by GitHub Actions workflow of sigstore/sigstore-js
+func loadSigstoreFixtures(t *testing.T) (*TrustedRoot, *sigstoreBundle, []byte) {
+	trustedRoot, err := GetTrustedRoot("testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile("testdata/sigstore-js-2.0.0.sigstore.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := parseSigstoreBundle(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := base64.StdEncoding.DecodeString(b.DsseEnvelope.Signatures[0].Sig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return trustedRoot, b, sig
+}
+
+func TestVerifyBundleCert(t *testing.T) {
+	tests := []struct {
+		name   string
+		repo   string
+		modify func(*TrustedRoot, *sigstoreBundle, []byte) []byte
+		valid  bool
+	}{
+		{"workflow of repo", "sigstore/sigstore-js", nil, true},
+		{"repo name is case insensitive", "Sigstore/Sigstore-JS", nil, true},
+		{"workflow of other repo", "pgulb/puff", nil, false},
+		{
+			"tampered signed entry timestamp", "sigstore/sigstore-js",
+			func(tr *TrustedRoot, b *sigstoreBundle, sig []byte) []byte {
+				b.VerificationMaterial.TlogEntries[0].IntegratedTime++
+				return sig
+			},
+			false,
+		},
+		{
+			"entry records other signature", "sigstore/sigstore-js",
+			func(tr *TrustedRoot, b *sigstoreBundle, sig []byte) []byte {
+				return bytes.Repeat([]byte{1}, len(sig))
+			},
+			false,
+		},
+		{
+			"no transparency log entry", "sigstore/sigstore-js",
+			func(tr *TrustedRoot, b *sigstoreBundle, sig []byte) []byte {
+				b.VerificationMaterial.TlogEntries = nil
+				return sig
+			},
+			false,
+		},
+		{
+			"log not in trust root", "sigstore/sigstore-js",
+			func(tr *TrustedRoot, b *sigstoreBundle, sig []byte) []byte {
+				tr.Tlogs = nil
+				return sig
+			},
+			false,
+		},
+		{
+			"no Fulcio CA in trust root", "sigstore/sigstore-js",
+			func(tr *TrustedRoot, b *sigstoreBundle, sig []byte) []byte {
+				tr.CertificateAuthorities = nil
+				return sig
+			},
+			false,
+		},
+		{
+			"no certificate", "sigstore/sigstore-js",
+			func(tr *TrustedRoot, b *sigstoreBundle, sig []byte) []byte {
+				b.VerificationMaterial.X509CertificateChain.Certificates = nil
+				return sig
+			},
+			false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trustedRoot, b, sig := loadSigstoreFixtures(t)
+			if tt.modify != nil {
+				sig = tt.modify(trustedRoot, b, sig)
+			}
+			pub, err := verifyBundleCert(trustedRoot, b, sig, &Repo{Path: tt.repo})
+			if tt.valid && err != nil {
+				t.Fatalf("expected valid bundle, got %v", err)
+			}
+			if !tt.valid && err == nil {
+				t.Fatal("expected bundle to be rejected")
+			}
+			if pub == nil {
+				return
+			}
+			payload, err := base64.StdEncoding.DecodeString(b.DsseEnvelope.Payload)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !verifyDsseSignature(pub, b.DsseEnvelope.PayloadType, payload, sig) {
+				t.Error("DSSE signature of bundle should be valid")
+			}
+			if verifyDsseSignature(pub, b.DsseEnvelope.PayloadType, append(payload, ' '), sig) {
+				t.Error("DSSE signature of tampered payload should be invalid")
+			}
+		})
+	}
+}
+
+func TestVerifySigstoreBundleSubject(t *testing.T) {
+	trustedRoot, b, _ := loadSigstoreFixtures(t)
+	// statement names npm tarball by sha512 only, so no sha256 can match
+	err := verifySigstoreBundle(trustedRoot, b, bytes.Repeat([]byte{0xab}, 32), &Repo{Path: "sigstore/sigstore-js"})
+	if err == nil || !strings.Contains(err.Error(), "subject") {
+		t.Errorf("expected subject mismatch, got %v", err)
+	}
+}
+
+func TestParseSigstoreBundle(t *testing.T) {
+	tests := []struct {
+		data   string
+		bundle bool
+	}{
+		{`{"mediaType": "application/vnd.dev.sigstore.bundle.v0.3+json"}`, true},
+		{`{"mediaType": "application/vnd.dev.sigstore.bundle+json;version=0.1"}`, true},
+		{`{"base64Signature": "MEUC", "cert": "LS0t"}`, false},
+		{`{"payloadType": "application/vnd.in-toto+json", "payload": "e30=", "signatures": []}`, false},
+		{`not json`, false},
+	}
+	for _, tt := range tests {
+		if _, err := parseSigstoreBundle([]byte(tt.data)); (err == nil) != tt.bundle {
+			t.Errorf("parseSigstoreBundle(%s) = %v, want bundle %v", tt.data, err, tt.bundle)
+		}
+	}
+}
+
+func TestDssePAE(t *testing.T) {
+	// example from DSSE protocol specification
+	pae := dssePAE("http://example.com/HelloWorld", []byte("hello world"))
+	if want := "DSSEv1 29 http://example.com/HelloWorld 11 hello world"; string(pae) != want {
+		t.Errorf("dssePAE = %q, want %q", pae, want)
+	}
+}
+
+func TestStatementHasSubject(t *testing.T) {
+	hash := strings.Repeat("ab", 32)
+	statement := `{"subject": [{"name": "tool.tar.gz", "digest": {"sha256": "` + hash + `"}}]}`
+	tests := []struct {
+		payload string
+		digest  string
+		found   bool
+	}{
+		{statement, hash, true},
+		{statement, strings.ToUpper(hash), true},
+		{statement, strings.Repeat("cd", 32), false},
+		{`{"subject": [{"digest": {"sha512": "` + hash + `"}}]}`, hash, false},
+		{`not json`, hash, false},
+	}
+	for _, tt := range tests {
+		if found := statementHasSubject([]byte(tt.payload), tt.digest); found != tt.found {
+			t.Errorf("statementHasSubject(%s, %s) = %v, want %v", tt.payload, tt.digest, found, tt.found)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package puff
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// how many previous versions of each package are kept for rollback
+const KeepVersions = 3
+
+// returns history of package with previous entry prepended,
+// versions above KeepVersions and reinstalled version are removed from store
+func pushHistory(cfgDir string, previous *Metadata, version string) []Metadata {
+	snapshot := *previous
+	snapshot.History = nil
+	var history []Metadata
+	for _, old := range append([]Metadata{snapshot}, previous.History...) {
+		if old.Version != version {
+			history = append(history, old)
+		}
+	}
+	for _, old := range history[min(len(history), KeepVersions):] {
+		fmt.Printf("removing %s %s from store\n", old.Path, old.Version)
+		os.RemoveAll(VersionDir(cfgDir, old.Path, old.Version))
+	}
+	return history[:min(len(history), KeepVersions)]
+}
+
+// copies fields describing installed version from history snapshot,
+// settings of package like pinned key or release policy are kept
+func restoreVersion(entry *Metadata, snapshot *Metadata) {
+	entry.Version = snapshot.Version
+	entry.Files = snapshot.Files
+	entry.Sha256 = snapshot.Sha256
+	entry.Signature = snapshot.Signature
+	entry.Provenance = snapshot.Provenance
+	entry.Platform = snapshot.Platform
+	entry.BinName = snapshot.BinName
+	entry.Aliases = snapshot.Aliases
+}
+
+// switches package to version from its history by relinking bin,
+// active version goes to history, package is held if it was held
+// or newer version exists
+func switchVersion(cfgDir string, metadata *MetadataList, entry *Metadata, i int) error {
+	target := entry.History[i]
+	err := adoptLegacyVersion(cfgDir, entry)
+	if err != nil {
+		return err
+	}
+	err = adoptLegacyVersion(cfgDir, &target)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(VersionDir(cfgDir, target.Path, target.Version)); err != nil {
+		return fmt.Errorf("%s %s not found in store: %w", target.Path, target.Version, err)
+	}
+	fmt.Printf("switching %s from %s to %s\n", entry.Path, entry.Version, target.Version)
+	err = ActivateVersion(cfgDir, target.Path, target.Version, InstalledFiles(&target), InstalledFiles(entry))
+	if err != nil {
+		return err
+	}
+	err = removeStaleFiles(cfgDir, InstalledFiles(entry), InstalledFiles(&target))
+	if err != nil {
+		return err
+	}
+	err = linkAliases(cfgDir, entry.Path, slices.Concat(InstalledFiles(&target), InstalledFiles(entry)), entry.Aliases, target.Aliases)
+	if err != nil {
+		return err
+	}
+	current := *entry
+	current.History = nil
+	history := append([]Metadata{current}, slices.Delete(slices.Clone(entry.History), i, i+1)...)
+	restoreVersion(entry, &target)
+	entry.History = history
+	// explicit hold is kept even when switching to newest version
+	entry.Held = current.Held || slices.ContainsFunc(history, func(m Metadata) bool {
+		cmp, isSemver := CompareTags(m.Version, target.Version)
+		return !isSemver || cmp > 0
+	})
+	err = SaveMetadata(metadata, cfgDir)
+	if err != nil {
+		return err
+	}
+	if entry.Held {
+		fmt.Printf("%s switched to %s and held, run puff unhold %s to update it again\n", entry.Path, target.Version, entry.Path)
+	} else {
+		fmt.Printf("%s switched to %s\n", entry.Path, target.Version)
+	}
+	return nil
+}
+
+// handling rollback command, switches package to newest version
+// from store older than the active one
+func Rollback(cfgDir string, path string) error {
+	metadata, err := GetMetadata(cfgDir)
+	if err != nil {
+		return err
+	}
+	entry := GetMetaEntry(metadata, path)
+	if entry == nil {
+		return fmt.Errorf("%s is not installed", path)
+	}
+	if len(entry.History) == 0 {
+		return fmt.Errorf("no previous version of %s to roll back to", path)
+	}
+	previous := -1
+	for i, m := range entry.History {
+		older, isSemver := CompareTags(m.Version, entry.Version)
+		if !isSemver || older >= 0 {
+			continue
+		}
+		if previous < 0 {
+			previous = i
+		} else if newer, _ := CompareTags(m.Version, entry.History[previous].Version); newer > 0 {
+			previous = i
+		}
+	}
+	if previous < 0 {
+		// versions can not be compared, last replaced one is used
+		previous = 0
+	}
+	return switchVersion(cfgDir, metadata, entry, previous)
+}
+
+// handling use command, switches package to version kept in store
+func Use(cfgDir string, path string, version string) error {
+	metadata, err := GetMetadata(cfgDir)
+	if err != nil {
+		return err
+	}
+	entry := GetMetaEntry(metadata, path)
+	if entry == nil {
+		return fmt.Errorf("%s is not installed", path)
+	}
+	if entry.Version == version {
+		fmt.Printf("%s %s already in use\n", path, version)
+		return nil
+	}
+	i := slices.IndexFunc(entry.History, func(m Metadata) bool {
+		return m.Version == version
+	})
+	if i < 0 {
+		var available []string
+		for _, m := range entry.History {
+			available = append(available, m.Version)
+		}
+		return fmt.Errorf(
+			"%s %s not in store, available: %s (install it with puff add %s@%s)",
+			path,
+			version,
+			strings.Join(append([]string{entry.Version}, available...), ", "),
+			path,
+			version,
+		)
+	}
+	return switchVersion(cfgDir, metadata, entry, i)
+}
@@ -0,0 +1,291 @@
+package puff
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// assets that are never installable binaries:
+// checksums, signatures, SBOMs, source archives, packages and docs
+var nonBinaryAssetRegexp = regexp.MustCompile(
+	`(?i)(` +
+		`\.(sha\d*|sha\d+sum|md5|sum|sig|asc|gpg|minisig|sshsig|pem|crt|cert|sigstore|bundle|intoto\.jsonl)$|` +
+		`(sha\d*sums?|checksums?)(\.txt)?$|\.sigstore\.json$|` +
+		`[-_.](sbom|spdx|cyclonedx|cdx)([-_.]|$)|` +
+		`[-_.](src|source|sources|vendor)([-_.]|$)|` +
+		`\.(deb|rpm|apk|msi|exe|dmg|pkg|snap|flatpak|whl|jar|txt|md|json|ya?ml|html|pdf|sh|ps1|bat)$|` +
+		// man pages, versions like tool-1.2.1 end with digit before the dot
+		`[^\d.]\.[1-9](\.gz)?$` +
+		`)`,
+)
+
+// matches version number inside tag, like 1.4.2 in cli-v1.4.2
+var versionRegexp = regexp.MustCompile(`\d+(\.\d+)+`)
+
+// regexp replacing version in learned asset patterns
+const versionWildcard = `v?\d+(\.\d+)*(-[0-9A-Za-z.]+)?`
+
+// returned when no asset in release matches pattern of installed repo
+var ErrNoMatchingAsset = errors.New("no matching asset")
+
+// operating systems recognized in asset names besides osAliases
+var otherOSNames = []string{
+	"windows", "win32", "win64", "freebsd", "openbsd", "netbsd",
+	"dragonfly", "android", "illumos", "solaris", "aix", "plan9",
+}
+
+// asset formats puff can install, ranked by preference
+var formatScores = []struct {
+	suffix string
+	score  int
+}{
+	{".tar.gz", 4},
+	{".tgz", 4},
+	{".tar.xz", 4},
+	{".txz", 4},
+	{".tar.zst", 4},
+	{".tar.bz2", 4},
+	{".tbz", 4},
+	{".zip", 2},
+	{".gz", 2},
+	{".xz", 2},
+	{".zst", 2},
+	{".bz2", 2},
+	{".appimage", 1},
+}
+
+// weights of platform matches in asset score
+const (
+	osMatchScore   = 8
+	archMatchScore = 8
+	libcMatchScore = 2
+)
+
+// checks if lowercased asset name contains token not glued to other letters or digits
+func mentions(name string, token string) bool {
+	for i := 0; ; {
+		j := strings.Index(name[i:], token)
+		if j < 0 {
+			return false
+		}
+		start, end := i+j, i+j+len(token)
+		if (start == 0 || !isAlnum(name[start-1])) && (end == len(name) || !isAlnum(name[end])) {
+			return true
+		}
+		i = start + 1
+	}
+}
+
+// checks if byte is ascii letter or digit
+func isAlnum(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= '0' && c <= '9'
+}
+
+// checks if lowercased asset name mentions any of names
+func mentionsAny(name string, names []string) bool {
+	return slices.ContainsFunc(names, func(n string) bool {
+		return mentions(name, strings.ToLower(n))
+	})
+}
+
+// returns score of format suffix of asset, 3 for bare binaries,
+// -1 for formats that can not be installed
+func formatScore(name string) int {
+	for _, f := range formatScores {
+		if strings.HasSuffix(name, f.suffix) {
+			return f.score
+		}
+	}
+	dot := strings.LastIndex(name, ".")
+	if dot < 0 || len(name)-dot > 5 || strings.ContainsAny(name[dot:], "-_") {
+		// no extension, dots only in version number like tool-1.2-linux
+		return 3
+	}
+	if strings.Trim(name[dot+1:], "0123456789") == "" {
+		// version number at the end, like tool-1.2
+		return 3
+	}
+	return -1
+}
+
+// scores how well asset fits platform,
+// returns -1 if asset is not a binary or is built for another platform
+func ScoreAsset(assetName string, p Platform) int {
+	if nonBinaryAssetRegexp.MatchString(assetName) {
+		return -1
+	}
+	name := strings.ToLower(assetName)
+	// x86_64 spellings are normalized to amd64
+	name = strings.NewReplacer("x86_64", "amd64", "x86-64", "amd64").Replace(name)
+	score := formatScore(name)
+	if score < 0 {
+		return -1
+	}
+
+	for goos, names := range osAliases {
+		if goos != p.OS && mentionsAny(name, names) {
+			return -1
+		}
+	}
+	if mentionsAny(name, otherOSNames) {
+		return -1
+	}
+	if mentionsAny(name, osAliases[p.OS]) {
+		score += osMatchScore
+	}
+
+	if mentionsAny(name, archAliases[p.Arch]) {
+		score += archMatchScore
+	} else {
+		for arch, names := range archAliases {
+			if arch != p.Arch && mentionsAny(name, names) {
+				return -1
+			}
+		}
+	}
+
+	switch {
+	case p.Libc == "":
+	case mentions(name, p.Libc):
+		score += libcMatchScore
+	case p.Libc == "musl" && mentionsAny(name, []string{"gnu", "glibc"}):
+		// glibc binaries do not run on musl
+		return -1
+	}
+	return score
+}
+
+// returns installable assets ranked best first for platform,
+// assets built for other platforms are left out
+func RankAssets(assets []Asset, p Platform) []Asset {
+	scores := make(map[string]int)
+	var ranked []Asset
+	for _, asset := range assets {
+		score := ScoreAsset(asset.Name, p)
+		if score < 0 {
+			continue
+		}
+		scores[asset.Name] = score
+		ranked = append(ranked, asset)
+	}
+	slices.SortStableFunc(ranked, func(a, b Asset) int {
+		return scores[b.Name] - scores[a.Name]
+	})
+	return ranked
+}
+
+// picks best asset for platform,
+// returns all top scoring candidates if choice is ambiguous
+func SelectAsset(assets []Asset, p Platform) (*Asset, []Asset) {
+	ranked := RankAssets(assets, p)
+	if len(ranked) == 0 {
+		return nil, nil
+	}
+	best := ScoreAsset(ranked[0].Name, p)
+	top := 1
+	for top < len(ranked) && ScoreAsset(ranked[top].Name, p) == best {
+		top++
+	}
+	if top > 1 {
+		return nil, ranked[:top]
+	}
+	return &ranked[0], nil
+}
+
+// returns first asset containing all name parts,
+// skipping checksums, signatures and other non-binary assets
+func MatchNameParts(assets []Asset, nameParts []string) *Asset {
+	for i, asset := range assets {
+		if nonBinaryAssetRegexp.MatchString(asset.Name) {
+			continue
+		}
+		containsAll := true
+		for _, part := range nameParts {
+			if !strings.Contains(asset.Name, part) {
+				containsAll = false
+				break
+			}
+		}
+		if containsAll {
+			return &assets[i]
+		}
+	}
+	return nil
+}
+
+// derives asset regexp that matches next releases
+// by replacing version of release in asset name with a wildcard
+func LearnAssetRegexp(assetName string, version string) string {
+	for _, v := range []string{version, versionRegexp.FindString(version)} {
+		if v == "" || !strings.Contains(assetName, v) {
+			continue
+		}
+		parts := strings.Split(assetName, v)
+		for i := range parts {
+			parts[i] = regexp.QuoteMeta(parts[i])
+		}
+		return "^" + strings.Join(parts, versionWildcard) + "$"
+	}
+	return "^" + regexp.QuoteMeta(assetName) + "$"
+}
+
+// returns first asset matching regexp,
+// skipping checksums, signatures and other non-binary assets
+func MatchRegexp(assets []Asset, pattern string) (*Asset, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	for i, asset := range assets {
+		if !nonBinaryAssetRegexp.MatchString(asset.Name) && re.MatchString(asset.Name) {
+			return &assets[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// picks asset for custom repo, pattern and nameParts saved in metadata
+// take precedence, otherwise best asset for current platform is picked
+// automatically and user is asked only when candidates are ambiguous,
+// returns picked asset and name parts to save (nil for automatic selection)
+func PickCustomAsset(assets []Asset, pattern string, nameParts []string) (*Asset, []string, error) {
+	if pattern != "" {
+		asset, err := MatchRegexp(assets, pattern)
+		if err == nil && asset == nil {
+			err = fmt.Errorf("%w for %s", ErrNoMatchingAsset, pattern)
+		}
+		return asset, nameParts, err
+	}
+	if nameParts != nil {
+		asset := MatchNameParts(assets, nameParts)
+		if asset == nil {
+			return nil, nameParts, fmt.Errorf("%w for name parts %s", ErrNoMatchingAsset, strings.Join(nameParts, ", "))
+		}
+		return asset, nameParts, nil
+	}
+	p := CurrentPlatform()
+	asset, candidates := SelectAsset(assets, p)
+	if asset != nil {
+		fmt.Printf("picked %s for %s\n", asset.Name, p)
+		return asset, nil, nil
+	}
+	if candidates == nil {
+		fmt.Printf("no asset matches %s, pick manually\n", p)
+		candidates = assets
+	} else {
+		fmt.Printf("multiple assets match %s\n", p)
+	}
+	fmt.Println("\nAvailable binaries:")
+	for _, v := range candidates {
+		fmt.Println(v.Name)
+	}
+	nameParts = PromptForNameParts()
+	asset = MatchNameParts(candidates, nameParts)
+	if asset == nil {
+		return nil, nameParts, fmt.Errorf("%w for name parts %s", ErrNoMatchingAsset, strings.Join(nameParts, ", "))
+	}
+	return asset, nameParts, nil
+}
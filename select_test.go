@@ -0,0 +1,184 @@
+package puff
+
+import (
+	"regexp"
+	"testing"
+)
+
+var (
+	linuxAmd64 = Platform{OS: "linux", Arch: "amd64", Libc: "gnu"}
+	linuxMusl  = Platform{OS: "linux", Arch: "amd64", Libc: "musl"}
+	linuxArm64 = Platform{OS: "linux", Arch: "arm64", Libc: "gnu"}
+	linux386   = Platform{OS: "linux", Arch: "386", Libc: "gnu"}
+	darwinArm  = Platform{OS: "darwin", Arch: "arm64"}
+)
+
+func TestScoreAssetRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		p    Platform
+	}{
+		{"checksums.txt", linuxAmd64},
+		{"tool_1.2.3_linux_amd64.tar.gz.sha256", linuxAmd64},
+		{"tool_1.2.3_linux_amd64.tar.gz.sig", linuxAmd64},
+		{"tool_1.2.3_linux_amd64.sbom.json", linuxAmd64},
+		{"tool-1.2.3-src.tar.gz", linuxAmd64},
+		{"tool_1.2.3_amd64.deb", linuxAmd64},
+		{"tool-1.2.3-x86_64.rpm", linuxAmd64},
+		{"tool_1.2.3_windows_amd64.zip", linuxAmd64},
+		{"tool-x86_64-pc-windows-msvc.zip", linuxAmd64},
+		{"tool_1.2.3_darwin_amd64.tar.gz", linuxAmd64},
+		{"tool-aarch64-apple-darwin.tar.gz", linuxArm64},
+		{"tool_1.2.3_freebsd_amd64.tar.gz", linuxAmd64},
+		{"tool_1.2.3_linux_arm64.tar.gz", linuxAmd64},
+		{"tool-aarch64-unknown-linux-gnu.tar.gz", linuxAmd64},
+		{"tool_1.2.3_linux_amd64.tar.gz", linuxArm64},
+		{"tool-x86_64-unknown-linux-gnu.tar.gz", linux386},
+		{"tool-x86_64-unknown-linux-gnu.tar.gz", linuxMusl},
+		{"tool_linux_amd64.pkg", linuxAmd64},
+		{"tool.1", linuxAmd64},
+		{"tool.8.gz", linuxAmd64},
+	}
+	for _, tt := range tests {
+		if score := ScoreAsset(tt.name, tt.p); score >= 0 {
+			t.Errorf("ScoreAsset(%q, %s) = %d, want rejected", tt.name, tt.p, score)
+		}
+	}
+}
+
+func TestScoreAssetOrder(t *testing.T) {
+	tests := []struct {
+		better string
+		worse  string
+		p      Platform
+	}{
+		// platform named explicitly beats generic asset
+		{"tool_1.2.3_linux_amd64.tar.gz", "tool_1.2.3.tar.gz", linuxAmd64},
+		{"tool-x86_64-unknown-linux-gnu.tar.gz", "tool-linux.tar.gz", linuxAmd64},
+		// matching libc is preferred
+		{"tool-x86_64-unknown-linux-musl.tar.gz", "tool-x86_64-unknown-linux.tar.gz", linuxMusl},
+		{"tool-x86_64-unknown-linux-gnu.tar.gz", "tool-x86_64-unknown-linux-musl.tar.gz", linuxAmd64},
+		// tarballs beat bare binaries, bare binaries beat zips and AppImages
+		{"tool_linux_amd64.tar.gz", "tool_linux_amd64", linuxAmd64},
+		{"tool_linux_amd64", "tool_linux_amd64.zip", linuxAmd64},
+		{"tool_linux_amd64.zip", "tool_linux_amd64.AppImage", linuxAmd64},
+		{"tool-aarch64-apple-darwin.tar.gz", "tool-universal.tar.gz", darwinArm},
+		{"tool_Linux_i386.tar.gz", "tool_Linux.tar.gz", linux386},
+	}
+	for _, tt := range tests {
+		better, worse := ScoreAsset(tt.better, tt.p), ScoreAsset(tt.worse, tt.p)
+		if worse < 0 || better <= worse {
+			t.Errorf("ScoreAsset on %s: %q = %d should beat %q = %d", tt.p, tt.better, better, tt.worse, worse)
+		}
+	}
+}
+
+func TestSelectAsset(t *testing.T) {
+	assets := func(names ...string) []Asset {
+		var list []Asset
+		for _, name := range names {
+			list = append(list, Asset{Name: name})
+		}
+		return list
+	}
+	ripgrep := assets(
+		"ripgrep-14.1.1-aarch64-apple-darwin.tar.gz",
+		"ripgrep-14.1.1-aarch64-unknown-linux-gnu.tar.gz",
+		"ripgrep-14.1.1-aarch64-unknown-linux-gnu.tar.gz.sha256",
+		"ripgrep-14.1.1-x86_64-apple-darwin.tar.gz",
+		"ripgrep-14.1.1-x86_64-pc-windows-msvc.zip",
+		"ripgrep-14.1.1-x86_64-unknown-linux-musl.tar.gz",
+		"ripgrep-14.1.1-x86_64-unknown-linux-musl.tar.gz.sha256",
+		"ripgrep_14.1.1-1_amd64.deb",
+	)
+	tests := []struct {
+		assets []Asset
+		p      Platform
+		picked string
+		ties   int
+	}{
+		{ripgrep, linuxAmd64, "ripgrep-14.1.1-x86_64-unknown-linux-musl.tar.gz", 0},
+		{ripgrep, linuxMusl, "ripgrep-14.1.1-x86_64-unknown-linux-musl.tar.gz", 0},
+		{ripgrep, linuxArm64, "ripgrep-14.1.1-aarch64-unknown-linux-gnu.tar.gz", 0},
+		{ripgrep, darwinArm, "ripgrep-14.1.1-aarch64-apple-darwin.tar.gz", 0},
+		{ripgrep, linux386, "", 0},
+		{assets("tool_linux_amd64.tar.gz", "tool_linux_x86_64.tar.gz"), linuxAmd64, "", 2},
+		{assets("tool.1", "tool-linux-amd64-1.2.1", "tool-linux-amd64-1.2.1.sha256"), linuxAmd64, "tool-linux-amd64-1.2.1", 0},
+		{assets("tool.1.gz", "tool_linux_amd64_v2.1"), linuxAmd64, "tool_linux_amd64_v2.1", 0},
+	}
+	for _, tt := range tests {
+		asset, ties := SelectAsset(tt.assets, tt.p)
+		var picked string
+		if asset != nil {
+			picked = asset.Name
+		}
+		if picked != tt.picked || len(ties) != tt.ties {
+			t.Errorf("SelectAsset on %s = %q with %d ties, want %q with %d", tt.p, picked, len(ties), tt.picked, tt.ties)
+		}
+	}
+}
+
+func TestLearnAssetRegexp(t *testing.T) {
+	tests := []struct {
+		asset   string
+		version string
+		next    string
+		pattern string
+	}{
+		{
+			"tool_1.2.3_linux_amd64.tar.gz", "v1.2.3", "tool_1.10.0_linux_amd64.tar.gz",
+			`^tool_v?\d+(\.\d+)*(-[0-9A-Za-z.]+)?_linux_amd64\.tar\.gz$`,
+		},
+		{
+			"tool-v1.2.3-x86_64-unknown-linux-gnu.tar.gz", "v1.2.3", "tool-v2.0.0-rc.1-x86_64-unknown-linux-gnu.tar.gz",
+			`^tool-v?\d+(\.\d+)*(-[0-9A-Za-z.]+)?-x86_64-unknown-linux-gnu\.tar\.gz$`,
+		},
+		{
+			"cli-1.4.2-linux-arm64", "cli-v1.4.2", "cli-1.5.0-linux-arm64",
+			`^cli-v?\d+(\.\d+)*(-[0-9A-Za-z.]+)?-linux-arm64$`,
+		},
+		{
+			"tool-linux-amd64", "v1.2.3", "tool-linux-amd64",
+			`^tool-linux-amd64$`,
+		},
+	}
+	for _, tt := range tests {
+		pattern := LearnAssetRegexp(tt.asset, tt.version)
+		if pattern != tt.pattern {
+			t.Errorf("LearnAssetRegexp(%q, %q) = %s, want %s", tt.asset, tt.version, pattern, tt.pattern)
+			continue
+		}
+		re := regexp.MustCompile(pattern)
+		if !re.MatchString(tt.asset) || !re.MatchString(tt.next) {
+			t.Errorf("%s should match %q and %q", pattern, tt.asset, tt.next)
+		}
+		if re.MatchString(tt.asset + ".sha256") {
+			t.Errorf("%s should not match checksum of %q", pattern, tt.asset)
+		}
+	}
+}
+
+func TestResolveRegexp(t *testing.T) {
+	tests := []struct {
+		pattern string
+		p       Platform
+		name    string
+		matches bool
+	}{
+		{`^zellij.*{arch}.*{os}.*`, linux386, "zellij-x86_64-unknown-linux-musl.tar.gz", false},
+		{`^zellij.*{arch}.*{os}.*`, linuxAmd64, "zellij-x86_64-unknown-linux-musl.tar.gz", true},
+		{`{os}_{arch}\.tar\.gz$`, linuxAmd64, "glow_Linux_x86_64.tar.gz", true},
+		{`{os}_{arch}\.tar\.gz$`, linuxArm64, "glow_Linux_x86_64.tar.gz", false},
+		{`{os}_{arch}\.tar\.gz$`, darwinArm, "glow_Darwin_arm64.tar.gz", true},
+		{`^tool-{arch}-unknown-linux-{libc}$`, linuxMusl, "tool-amd64-unknown-linux-musl", true},
+		{`^jq-{os}-?{arch}$`, linuxAmd64, "jq-linux-amd64", true},
+		{`^jq-{os}-?{arch}$`, linuxArm64, "jq-linux-arm64", true},
+		{`^jq-{os}-?{arch}$`, linuxArm64, "jq-linux64", false},
+	}
+	for _, tt := range tests {
+		re := regexp.MustCompile(ResolveRegexp(tt.pattern, tt.p))
+		if re.MatchString(tt.name) != tt.matches {
+			t.Errorf("%s resolved for %s matching %q = %v, want %v", tt.pattern, tt.p, tt.name, !tt.matches, tt.matches)
+		}
+	}
+}
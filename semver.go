@@ -0,0 +1,249 @@
+package puff
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// SemVer holds semantic version parsed from release tag
+type SemVer struct {
+	Major int
+	Minor int
+	Patch int
+	// prerelease identifiers, like rc.1
+	Pre string
+}
+
+// matches version at the end of tag, prefixes like v or cli-v are skipped
+var semverRegexp = regexp.MustCompile(
+	`(?:^|[^0-9.])(\d+)(?:\.(\d+))?(?:\.(\d+))?(?:-([0-9A-Za-z.-]+))?(?:\+[0-9A-Za-z.-]+)?$`,
+)
+
+// matches date in tag, like nightly-2024-01-15 or build-20240115
+var dateTagRegexp = regexp.MustCompile(`(^|\D)(19|20)\d{2}-?(0[1-9]|1[0-2])-?(0[1-9]|[12]\d|3[01])(\D|$)`)
+
+// parses semantic version from tag like v1.2.3, cli/v1.2 or 1.2.3-rc.1,
+// missing minor and patch are zero, returns false if tag has no version
+// or is dated like nightly-2024-01-15
+func ParseVersion(tag string) (SemVer, bool) {
+	tag = strings.TrimSpace(tag)
+	if dateTagRegexp.MatchString(tag) {
+		return SemVer{}, false
+	}
+	m := semverRegexp.FindStringSubmatch(tag)
+	if m == nil {
+		return SemVer{}, false
+	}
+	var v SemVer
+	v.Major, _ = strconv.Atoi(m[1])
+	v.Minor, _ = strconv.Atoi(m[2])
+	v.Patch, _ = strconv.Atoi(m[3])
+	v.Pre = m[4]
+	return v, true
+}
+
+func (v SemVer) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.Pre != "" {
+		s += "-" + v.Pre
+	}
+	return s
+}
+
+// compares two integers, returns -1, 0 or 1
+func compareInts(a int, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// compares prerelease identifiers by semver precedence,
+// version without prerelease is greater than one with it
+func comparePre(a string, b string) int {
+	if a == b {
+		return 0
+	}
+	if a == "" {
+		return 1
+	}
+	if b == "" {
+		return -1
+	}
+	aParts, bParts := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(aParts) && i < len(bParts); i++ {
+		aNum, aErr := strconv.Atoi(aParts[i])
+		bNum, bErr := strconv.Atoi(bParts[i])
+		var c int
+		switch {
+		case aErr == nil && bErr == nil:
+			c = compareInts(aNum, bNum)
+		case aErr == nil:
+			// numeric identifiers have lower precedence
+			c = -1
+		case bErr == nil:
+			c = 1
+		default:
+			c = strings.Compare(aParts[i], bParts[i])
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return compareInts(len(aParts), len(bParts))
+}
+
+// compares versions, returns -1 if v is older than o, 1 if newer, 0 if equal
+func (v SemVer) Compare(o SemVer) int {
+	if c := compareInts(v.Major, o.Major); c != 0 {
+		return c
+	}
+	if c := compareInts(v.Minor, o.Minor); c != 0 {
+		return c
+	}
+	if c := compareInts(v.Patch, o.Patch); c != 0 {
+		return c
+	}
+	return comparePre(v.Pre, o.Pre)
+}
+
+// compares two tags, returns false if any of them is not a version
+func CompareTags(a string, b string) (int, bool) {
+	aVer, aOk := ParseVersion(a)
+	bVer, bOk := ParseVersion(b)
+	if !aOk || !bOk {
+		return 0, false
+	}
+	return aVer.Compare(bVer), true
+}
+
+// single comparison of constraint, like >=1.4.0
+type comparator struct {
+	op      string
+	version SemVer
+}
+
+// checks if version satisfies comparison
+func (c comparator) allows(v SemVer) bool {
+	cmp := v.Compare(c.version)
+	switch c.op {
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	}
+	return cmp == 0
+}
+
+// Constraint holds version range, all comparators must be satisfied
+type Constraint struct {
+	comparators []comparator
+}
+
+// matches version given in constraint, like 1.4 or v1.2.3-rc.1
+var constraintVersionRegexp = regexp.MustCompile(`^[vV]?(\d+(?:\.\d+){0,2})(?:-[0-9A-Za-z.-]+)?(?:\+[0-9A-Za-z.-]+)?$`)
+
+// parses version given in constraint, returns number of given parts
+func parseConstraintVersion(s string) (SemVer, int, error) {
+	m := constraintVersionRegexp.FindStringSubmatch(s)
+	if m == nil {
+		return SemVer{}, 0, fmt.Errorf("invalid version %q in constraint", s)
+	}
+	v, _ := ParseVersion(s)
+	return v, strings.Count(m[1], ".") + 1, nil
+}
+
+// parses constraint like ~1.4, ^1.2.3, <2.0.0 or >=1.2, <2,
+// comparators are separated by commas or spaces, operator may be
+// separated from its version too (>= 1.2), bare version means exact match
+// and * any version
+func ParseConstraint(s string) (*Constraint, error) {
+	c := &Constraint{}
+	fields := strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == ' '
+	})
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("empty version constraint")
+	}
+	for i := 0; i < len(fields); i++ {
+		field := fields[i]
+		if field == "*" {
+			continue
+		}
+		if strings.Trim(field, "<>=~^") == "" && i+1 < len(fields) {
+			// operator separated from version by space
+			i++
+			field += fields[i]
+		}
+		rest := strings.TrimLeft(field, "<>=~^")
+		op := field[:len(field)-len(rest)]
+		v, parts, err := parseConstraintVersion(rest)
+		if err != nil {
+			return nil, err
+		}
+		var upper SemVer
+		switch op {
+		case "~":
+			// ~1.4.2 and ~1.4 allow patch updates, ~1 allows minor updates
+			upper = SemVer{Major: v.Major, Minor: v.Minor + 1}
+			if parts == 1 {
+				upper = SemVer{Major: v.Major + 1}
+			}
+		case "^":
+			// allows updates not changing leftmost non-zero part
+			switch {
+			case v.Major > 0 || parts == 1:
+				upper = SemVer{Major: v.Major + 1}
+			case v.Minor > 0 || parts == 2:
+				upper = SemVer{Minor: v.Minor + 1}
+			default:
+				upper = SemVer{Patch: v.Patch + 1}
+			}
+		case "<", "<=", ">", ">=", "=", "":
+			c.comparators = append(c.comparators, comparator{op, v})
+			continue
+		default:
+			return nil, fmt.Errorf("invalid operator %s in constraint", op)
+		}
+		c.comparators = append(c.comparators, comparator{">=", v}, comparator{"<", upper})
+	}
+	return c, nil
+}
+
+// checks if version satisfies all comparators,
+// prereleases are allowed only if constraint names prerelease of same version
+func (c *Constraint) Allows(v SemVer) bool {
+	if v.Pre != "" {
+		named := false
+		for _, comp := range c.comparators {
+			cv := comp.version
+			if cv.Pre != "" && cv.Major == v.Major && cv.Minor == v.Minor && cv.Patch == v.Patch {
+				named = true
+			}
+		}
+		if !named {
+			return false
+		}
+	}
+	for _, comp := range c.comparators {
+		if !comp.allows(v) {
+			return false
+		}
+	}
+	return true
+}
+
+// checks if tag satisfies constraint, tags without version never do
+func (c *Constraint) AllowsTag(tag string) bool {
+	v, ok := ParseVersion(tag)
+	return ok && c.Allows(v)
+}
@@ -0,0 +1,128 @@
+package puff
+
+import "testing"
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		tag     string
+		version SemVer
+		ok      bool
+	}{
+		{"v1.2.3", SemVer{1, 2, 3, ""}, true},
+		{"1.2.3", SemVer{1, 2, 3, ""}, true},
+		{"v1.2", SemVer{1, 2, 0, ""}, true},
+		{"v2", SemVer{2, 0, 0, ""}, true},
+		{"1.2.3-rc.1", SemVer{1, 2, 3, "rc.1"}, true},
+		{"v1.2.3+build.5", SemVer{1, 2, 3, ""}, true},
+		{"cli-v1.4.2", SemVer{1, 4, 2, ""}, true},
+		{"kustomize/v5.4.0", SemVer{5, 4, 0, ""}, true},
+		{"v2024.1.0", SemVer{2024, 1, 0, ""}, true},
+		{"nightly", SemVer{}, false},
+		{"nightly-2024-01-15", SemVer{}, false},
+		{"build-20240115", SemVer{}, false},
+	}
+	for _, tt := range tests {
+		version, ok := ParseVersion(tt.tag)
+		if ok != tt.ok || version != tt.version {
+			t.Errorf("ParseVersion(%q) = %v, %v, want %v, %v", tt.tag, version, ok, tt.version, tt.ok)
+		}
+	}
+}
+
+func TestCompareTags(t *testing.T) {
+	tests := []struct {
+		a   string
+		b   string
+		cmp int
+		ok  bool
+	}{
+		{"v1.2.3", "v1.2.3", 0, true},
+		{"v1.2.3", "1.2.3", 0, true},
+		{"v1.2.4", "v1.2.3", 1, true},
+		{"v1.10.0", "v1.9.0", 1, true},
+		{"v2.0.0", "v1.99.99", 1, true},
+		{"v1.0.0-rc.1", "v1.0.0", -1, true},
+		{"v1.0.0-alpha", "v1.0.0-alpha.1", -1, true},
+		{"v1.0.0-alpha.1", "v1.0.0-alpha.beta", -1, true},
+		{"v1.0.0-beta.2", "v1.0.0-beta.11", -1, true},
+		{"v1.0.0-rc.1", "v1.0.0-beta.11", 1, true},
+		{"v1.0.0", "nightly", 0, false},
+		{"nightly-2024-01-15", "nightly-2023-12-31", 0, false},
+	}
+	for _, tt := range tests {
+		cmp, ok := CompareTags(tt.a, tt.b)
+		if cmp != tt.cmp || ok != tt.ok {
+			t.Errorf("CompareTags(%q, %q) = %d, %v, want %d, %v", tt.a, tt.b, cmp, ok, tt.cmp, tt.ok)
+		}
+	}
+}
+
+func TestConstraintAllows(t *testing.T) {
+	tests := []struct {
+		constraint string
+		allowed    []string
+		denied     []string
+	}{
+		{"~1.4", []string{"v1.4.0", "v1.4.9"}, []string{"v1.3.9", "v1.5.0", "v2.0.0"}},
+		{"~1.4.2", []string{"v1.4.2", "v1.4.9"}, []string{"v1.4.1", "v1.5.0"}},
+		{"~1", []string{"v1.0.0", "v1.9.0"}, []string{"v0.9.0", "v2.0.0"}},
+		{"^1.2.3", []string{"v1.2.3", "v1.9.0"}, []string{"v1.2.2", "v2.0.0"}},
+		{"^0.2.3", []string{"v0.2.3", "v0.2.9"}, []string{"v0.3.0", "v1.0.0"}},
+		{"^0.0.3", []string{"v0.0.3"}, []string{"v0.0.4", "v0.1.0"}},
+		{"^0", []string{"v0.0.1", "v0.9.0"}, []string{"v1.0.0"}},
+		{"<2.0.0", []string{"v1.9.9"}, []string{"v2.0.0", "v2.0.0-rc.1"}},
+		{"<=2.0.0", []string{"v2.0.0"}, []string{"v2.0.1"}},
+		{">1.2", []string{"v1.2.1"}, []string{"v1.2.0"}},
+		{">=1.2, <2", []string{"v1.2.0", "v1.9.9"}, []string{"v1.1.9", "v2.0.0"}},
+		{">=1.2 <2", []string{"v1.2.0", "v1.9.9"}, []string{"v1.1.9", "v2.0.0"}},
+		{">= 1.2 < 2", []string{"v1.2.0", "v1.9.9"}, []string{"v1.1.9", "v2.0.0"}},
+		{"=1.2.3", []string{"v1.2.3"}, []string{"v1.2.4"}},
+		{"1.2.3", []string{"1.2.3"}, []string{"v1.2.4"}},
+		{"*", []string{"v0.0.1", "v9.0.0"}, []string{"v1.0.0-rc.1", "nightly"}},
+		{">=1.0.0-rc.1", []string{"v1.0.0-rc.2", "v1.0.0", "v1.1.0"}, []string{"v1.0.0-beta.1", "v1.1.0-rc.1"}},
+	}
+	for _, tt := range tests {
+		c, err := ParseConstraint(tt.constraint)
+		if err != nil {
+			t.Errorf("ParseConstraint(%q): %v", tt.constraint, err)
+			continue
+		}
+		for _, tag := range tt.allowed {
+			if !c.AllowsTag(tag) {
+				t.Errorf("%q should allow %s", tt.constraint, tag)
+			}
+		}
+		for _, tag := range tt.denied {
+			if c.AllowsTag(tag) {
+				t.Errorf("%q should not allow %s", tt.constraint, tag)
+			}
+		}
+	}
+}
+
+func TestParseConstraintErrors(t *testing.T) {
+	for _, s := range []string{"", " , ", ">=", "~>1.2", "1.x", ">=abc", "1.2.3.4"} {
+		if _, err := ParseConstraint(s); err == nil {
+			t.Errorf("ParseConstraint(%q) should fail", s)
+		}
+	}
+}
+
+func TestIsMajorBump(t *testing.T) {
+	tests := []struct {
+		from string
+		to   string
+		bump bool
+	}{
+		{"v1.9.0", "v2.0.0", true},
+		{"v1.2.0", "v1.3.0", false},
+		{"v0.2.0", "v0.3.0", false},
+		{"", "v2.0.0", false},
+		{"nightly-2023-12-31", "nightly-2024-01-01", false},
+	}
+	for _, tt := range tests {
+		if bump := IsMajorBump(tt.from, tt.to); bump != tt.bump {
+			t.Errorf("IsMajorBump(%q, %q) = %v, want %v", tt.from, tt.to, bump, tt.bump)
+		}
+	}
+}
@@ -150,3 +150,23 @@ func MaybeCreateMetadata(cfgDir string) error {
 	}
 	return nil
 }
+
+// creates directory for in-progress downloads, returns its path
+func MustCreateDownloadDir(cfgDir string) (string, error) {
+	downloadDir := filepath.Join(cfgDir, "downloads")
+	err := os.MkdirAll(downloadDir, 0750)
+	if err != nil {
+		return "", err
+	}
+	return downloadDir, nil
+}
+
+// creates store directory of package version, returns its path
+func MustCreateVersionDir(cfgDir string, path string, version string) (string, error) {
+	versionDir := VersionDir(cfgDir, path, version)
+	err := os.MkdirAll(versionDir, 0750)
+	if err != nil {
+		return "", err
+	}
+	return versionDir, nil
+}
@@ -0,0 +1,354 @@
+package puff
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/ssh"
+)
+
+// suffixes of detached signature files for each key type
+var signatureSuffixes = map[string][]string{
+	"minisign": {".minisig"},
+	"gpg":      {".asc", ".sig", ".gpg"},
+	"ssh":      {".sshsig", ".sig"},
+}
+
+// namespace used by ssh-keygen -Y sign for files
+const sshsigNamespace = "file"
+
+// reads public key given as literal value or as @path to file
+func ReadPublicKey(value string) (string, error) {
+	path, isFile := strings.CutPrefix(value, "@")
+	if !isFile {
+		return strings.TrimSpace(value), nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// returns last line of text that is not a comment
+func lastDataLine(text string) string {
+	var last string
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "untrusted comment:") {
+			continue
+		}
+		last = line
+	}
+	return last
+}
+
+// detects type of public key: minisign, gpg or ssh
+func publicKeyType(key string) (string, error) {
+	if strings.Contains(key, "BEGIN PGP PUBLIC KEY BLOCK") {
+		return "gpg", nil
+	}
+	if _, _, _, _, err := ssh.ParseAuthorizedKey([]byte(key)); err == nil {
+		return "ssh", nil
+	}
+	raw, err := base64.StdEncoding.DecodeString(lastDataLine(key))
+	if err == nil && len(raw) == 42 && string(raw[:2]) == "Ed" {
+		return "minisign", nil
+	}
+	return "", errors.New("unrecognized public key, expected minisign, GPG or SSH key")
+}
+
+// verifies minisign signature of content
+func verifyMinisign(content io.Reader, key string, sig []byte) error {
+	rawKey, err := base64.StdEncoding.DecodeString(lastDataLine(key))
+	if err != nil {
+		return err
+	}
+	keyID, pk := rawKey[2:10], ed25519.PublicKey(rawKey[10:42])
+
+	lines := strings.Split(strings.TrimSpace(string(sig)), "\n")
+	if len(lines) != 4 {
+		return errors.New("malformed minisign signature")
+	}
+	rawSig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[1]))
+	if err != nil || len(rawSig) != 74 {
+		return errors.New("malformed minisign signature")
+	}
+	if !bytes.Equal(rawSig[2:10], keyID) {
+		return errors.New("minisign signature made with different key")
+	}
+	var data []byte
+	switch string(rawSig[:2]) {
+	case "ED":
+		// prehashed signature
+		h, err := blake2b.New512(nil)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(h, content)
+		if err != nil {
+			return err
+		}
+		data = h.Sum(nil)
+	case "Ed":
+		// legacy signature over whole content
+		data, err = io.ReadAll(content)
+		if err != nil {
+			return err
+		}
+	default:
+		return errors.New("unsupported minisign signature algorithm")
+	}
+	if !ed25519.Verify(pk, data, rawSig[10:]) {
+		return errors.New("invalid minisign signature")
+	}
+	trustedComment, found := strings.CutPrefix(strings.TrimSpace(lines[2]), "trusted comment: ")
+	if !found {
+		return errors.New("malformed minisign trusted comment")
+	}
+	globalSig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[3]))
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(pk, slices.Concat(rawSig[10:], []byte(trustedComment)), globalSig) {
+		return errors.New("invalid minisign trusted comment signature")
+	}
+	return nil
+}
+
+// verifies detached GPG signature of content, armored or binary
+func verifyGpg(content io.Reader, key string, sig []byte) error {
+	keyring, err := openpgp.ReadArmoredKeyRing(strings.NewReader(key))
+	if err != nil {
+		return err
+	}
+	if bytes.Contains(sig, []byte("BEGIN PGP SIGNATURE")) {
+		_, err = openpgp.CheckArmoredDetachedSignature(keyring, content, bytes.NewReader(sig), nil)
+	} else {
+		_, err = openpgp.CheckDetachedSignature(keyring, content, bytes.NewReader(sig), nil)
+	}
+	return err
+}
+
+// reads SSH wire format string from buffer
+func readSSHString(buf *bytes.Reader) ([]byte, error) {
+	var size uint32
+	err := binary.Read(buf, binary.BigEndian, &size)
+	if err != nil {
+		return nil, err
+	}
+	if int64(size) > int64(buf.Len()) {
+		return nil, errors.New("malformed SSH signature")
+	}
+	s := make([]byte, size)
+	_, err = io.ReadFull(buf, s)
+	return s, err
+}
+
+// appends SSH wire format string to buffer
+func appendSSHString(b []byte, s []byte) []byte {
+	b = binary.BigEndian.AppendUint32(b, uint32(len(s)))
+	return append(b, s...)
+}
+
+// verifies signature of content made with ssh-keygen -Y sign
+func verifySSH(content io.Reader, key string, sig []byte) error {
+	pub, _, _, _, err := ssh.ParseAuthorizedKey([]byte(key))
+	if err != nil {
+		return err
+	}
+	block, _ := pem.Decode(sig)
+	if block == nil || block.Type != "SSH SIGNATURE" {
+		return errors.New("not an SSH signature")
+	}
+	buf := bytes.NewReader(block.Bytes)
+	magic := make([]byte, 6)
+	_, err = io.ReadFull(buf, magic)
+	if err != nil || string(magic) != "SSHSIG" {
+		return errors.New("malformed SSH signature")
+	}
+	var version uint32
+	err = binary.Read(buf, binary.BigEndian, &version)
+	if err != nil || version != 1 {
+		return errors.New("unsupported SSH signature version")
+	}
+	var fields [5][]byte
+	for i := range fields {
+		fields[i], err = readSSHString(buf)
+		if err != nil {
+			return err
+		}
+	}
+	sigKey, namespace, reserved, hashAlg, rawSig := fields[0], fields[1], fields[2], fields[3], fields[4]
+	if !bytes.Equal(sigKey, pub.Marshal()) {
+		return errors.New("SSH signature made with different key")
+	}
+	if string(namespace) != sshsigNamespace {
+		return fmt.Errorf("unexpected SSH signature namespace %s", namespace)
+	}
+	var h hash.Hash
+	switch string(hashAlg) {
+	case "sha256":
+		h = sha256.New()
+	case "sha512":
+		h = sha512.New()
+	default:
+		return fmt.Errorf("unsupported SSH signature hash %s", hashAlg)
+	}
+	_, err = io.Copy(h, content)
+	if err != nil {
+		return err
+	}
+	digest := h.Sum(nil)
+	signed := []byte("SSHSIG")
+	signed = appendSSHString(signed, namespace)
+	signed = appendSSHString(signed, reserved)
+	signed = appendSSHString(signed, hashAlg)
+	signed = appendSSHString(signed, digest)
+	var signature ssh.Signature
+	err = ssh.Unmarshal(rawSig, &signature)
+	if err != nil {
+		return err
+	}
+	return pub.Verify(signed, &signature)
+}
+
+// verifies content against detached signature with key of given type
+func verifyDetached(keyType string, content io.Reader, key string, sig []byte) error {
+	switch keyType {
+	case "minisign":
+		return verifyMinisign(content, key, sig)
+	case "gpg":
+		return verifyGpg(content, key, sig)
+	case "ssh":
+		return verifySSH(content, key, sig)
+	}
+	return fmt.Errorf("unsupported key type %s", keyType)
+}
+
+// returns detached signature of signedName published in release
+// or nil if there is none
+func findSignature(release *Release, keyType string, signedName string, ghPat string) (*Asset, []byte, error) {
+	for _, suffix := range signatureSuffixes[keyType] {
+		for _, asset := range release.Assets {
+			if asset.Name != signedName+suffix {
+				continue
+			}
+			sig, err := DownloadSmallAsset(asset.URL, ghPat)
+			if err != nil {
+				return nil, nil, err
+			}
+			return &asset, sig, nil
+		}
+	}
+	return nil, nil, nil
+}
+
+// verifies signature of downloaded asset itself,
+// returns name of verified signature or empty string if none published
+func verifyAssetSignature(assetPath string, release *Release, keyType string, key string, ghPat string) (string, error) {
+	sigAsset, sig, err := findSignature(release, keyType, release.Name, ghPat)
+	if err != nil || sigAsset == nil {
+		return "", err
+	}
+	f, err := os.Open(assetPath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	err = verifyDetached(keyType, f, key, sig)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", sigAsset.Name, err)
+	}
+	return sigAsset.Name, nil
+}
+
+// verifies signed checksum lists of release,
+// accepts asset if its sha256 is listed in a list with valid signature,
+// returns name of verified signature or empty string if none published
+func verifyChecksumListSignature(assetPath string, release *Release, keyType string, key string, ghPat string) (string, error) {
+	assetName := release.Name
+	for _, asset := range release.Assets {
+		if !checksumListRegexp.MatchString(asset.Name) {
+			continue
+		}
+		sigAsset, sig, err := findSignature(release, keyType, asset.Name, ghPat)
+		if err != nil {
+			return "", err
+		}
+		if sigAsset == nil {
+			continue
+		}
+		data, err := DownloadSmallAsset(asset.URL, ghPat)
+		if err != nil {
+			return "", err
+		}
+		err = verifyDetached(keyType, bytes.NewReader(data), key, sig)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", sigAsset.Name, err)
+		}
+		expected := findInChecksumList(data, assetName, false)
+		if expected == "" {
+			continue
+		}
+		actual, err := fileSha256(assetPath)
+		if err != nil {
+			return "", err
+		}
+		if actual != expected {
+			return "", fmt.Errorf("checksum of %s does not match signed %s", assetName, asset.Name)
+		}
+		return sigAsset.Name, nil
+	}
+	return "", nil
+}
+
+// verifies detached signature of downloaded asset with pinned public key,
+// signed checksum list containing the asset is accepted too,
+// returns description of verified signature or empty string if none found
+func VerifySignature(assetPath string, release *Release, repo *Repo, ghPat string) (string, error) {
+	if repo.PublicKey == "" {
+		if repo.RequireSignature {
+			return "", fmt.Errorf("signature required but no public key pinned for %s", repo.Path)
+		}
+		return "", nil
+	}
+	keyType, err := publicKeyType(repo.PublicKey)
+	if err != nil {
+		return "", err
+	}
+	assetName := release.Name
+	sigName, err := verifyAssetSignature(assetPath, release, keyType, repo.PublicKey, ghPat)
+	if err != nil {
+		return "", err
+	}
+	if sigName == "" {
+		sigName, err = verifyChecksumListSignature(assetPath, release, keyType, repo.PublicKey, ghPat)
+		if err != nil {
+			return "", err
+		}
+	}
+	if sigName == "" {
+		if repo.RequireSignature {
+			return "", fmt.Errorf("no valid %s signature found for %s, refusing to install", keyType, assetName)
+		}
+		fmt.Printf("no %s signature published for %s, skipping verification\n", keyType, assetName)
+		return "", nil
+	}
+	fmt.Printf("%s signature %s verified with pinned key\n", keyType, sigName)
+	return keyType + ":" + sigName, nil
+}
@@ -0,0 +1,140 @@
+package puff
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+// minisign fixtures made by minisign tool, content is "Hello, World!\n"
+const (
+	minisignContent = "Hello, World!\n"
+	minisignKey     = "untrusted comment: minisign public key 9D92931126AE543F\n" +
+		"RWQ/VK4mEZOSnVFf2NhEt9WV8zE1RcN8mtKeOO7mVjj/MCDvb5tSV6RD\n"
+	minisignSig = "untrusted comment: signature from minisign secret key\n" +
+		"RWQ/VK4mEZOSnVsP2aVAcwlCDu0V5VUqqGeE6mndH9v7wY4++PrZdB0HBRyVpt4/h0VDzQIvLenPpmTRSx1604Bac6Joz08phg4=\n" +
+		"trusted comment: timestamp:1610131681\tfile:hello_world.txt\n" +
+		"SbpK5wWmI+aoiwXBitvDWszRT9dwH8ZMzVaGn+WHZQXz+xnnAWCTmikCYnVv67iffkmZr24wZmnMok6Fvv8HDQ==\n"
+	minisignHashedKey = "untrusted comment: minisign public key 71418543D227848\n" +
+		"RWRIeCI9VBgUB0FAABABUrdfRVLBsRhOC63S9bDOAeWkCmnT38a1sUDb\n"
+	minisignHashedSig = "untrusted comment: signature from minisign secret key\n" +
+		"RURIeCI9VBgUB9kPHyUwRtxZycb78g9wT6d+oRuXEKquv665OMM6CI64Z+hGcKiJg2ErfA50FCgmdiUw4EHErNMivjYajjO4EAQ=\n" +
+		"trusted comment: timestamp:1643685548\tfile:hello_world.txt\thashed\n" +
+		"cueBI9ab3mX+ZGQoBFSq49wrxZMTrLjX1Q0LlNhUmnA7dIptKj/KrpbfDJDCPtbxd3lbeo0zKGVNwpW/EQo3Dw==\n"
+)
+
+// SSH and GPG fixtures made by ssh-keygen -Y sign and gpg --detach-sign,
+// content is "Hello, puff!\n"
+const (
+	signedContent = "Hello, puff!\n"
+	sshKey        = "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAILkLuxtUuuDw7HIn/XNoVhuhA9hUrGstzswZHL1iSLXx puff-test"
+	sshOtherKey   = "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIBwTv6+NbL5YIpUNCLZoOqjkMglMJ4AOTzlW4rQVTMb2 other"
+	sshSig        = `-----BEGIN SSH SIGNATURE-----
+U1NIU0lHAAAAAQAAADMAAAALc3NoLWVkMjU1MTkAAAAguQu7G1S64PDscif9c2hWG6ED2F
+Ssay3OzBkcvWJItfEAAAAEZmlsZQAAAAAAAAAGc2hhNTEyAAAAUwAAAAtzc2gtZWQyNTUx
+OQAAAEDEYB9K1e/wzbQ/YEwfkzNV1aS5fbLKwuAn34+aSyi4HFTazbeudpCUL+nxdioXD3
+4uxTU4Q/9poUfvU9iGcNcH
+-----END SSH SIGNATURE-----
+`
+	// signed with namespace git instead of file
+	sshGitSig = `-----BEGIN SSH SIGNATURE-----
+U1NIU0lHAAAAAQAAADMAAAALc3NoLWVkMjU1MTkAAAAguQu7G1S64PDscif9c2hWG6ED2F
+Ssay3OzBkcvWJItfEAAAADZ2l0AAAAAAAAAAZzaGE1MTIAAABTAAAAC3NzaC1lZDI1NTE5
+AAAAQALCnfJ1VFNMfOpt2mdLvWxYKmmRRHoJ7B2vBfVqh+olFiqB9FE9KauUdJZXvnKWXJ
+PtHq+vRIYVhjecko7kxg8=
+-----END SSH SIGNATURE-----
+`
+	gpgKey = `-----BEGIN PGP PUBLIC KEY BLOCK-----
+
+mDMEatLoHhYJKwYBBAHaRw8BAQdAkXpLLuacjVgsJTgWcbParjfdGuNA/qfgPRbU
+9huvGu60HHB1ZmYgdGVzdCA8dGVzdEBleGFtcGxlLmNvbT6IkAQTFggAOBYhBPVR
++94CXYOKFV5JWnQgts5SWCZPBQJq0ugeAhsDBQsJCAcCBhUKCQgLAgQWAgMBAh4B
+AheAAAoJEHQgts5SWCZPbEIA/0WS6PLgYxXy76H/RPw6ndlW+Ms2RZFX+ypTZ5jx
+ls/qAPoC9cYtl/o9kQs9vPZ9pi54RzcFd4bMeyMR3gdgaxK1DA==
+=mn/y
+-----END PGP PUBLIC KEY BLOCK-----`
+	gpgOtherKey = `-----BEGIN PGP PUBLIC KEY BLOCK-----
+
+mDMEatLoHhYJKwYBBAHaRw8BAQdA6d0e77Mxrf+kT5kbS1mq5kqT5vTSH2jqrTw5
+iaHqXJm0FW90aGVyIDxvQGV4YW1wbGUuY29tPoiQBBMWCAA4FiEEIsFe7PcCHoXa
+SKkx9YY+ZvEb6L8FAmrS6B4CGwMFCwkIBwIGFQoJCAsCBBYCAwECHgECF4AACgkQ
+9YY+ZvEb6L9saQEApVWABgcZFeiDmHjmp3cLe534O0W15zW4mPk/9A4cJYkBAKfw
+C7B6uYhGGgKFAbeZrJeg+v9PzJt9GRvyGdHNgi4A
+=6aoo
+-----END PGP PUBLIC KEY BLOCK-----`
+	gpgArmoredSig = `-----BEGIN PGP SIGNATURE-----
+
+iHUEABYIAB0WIQT1UfveAl2DihVeSVp0ILbOUlgmTwUCatLoHgAKCRB0ILbOUlgm
+T9zAAP0TcRZSDb+NhwClm62UBogx8lO3W49lB++DNzTbkBICfAD+Jir9HKruGUmo
+evLo1QTAZoGWacS6+ltDB82jpTdJsg0=
+=K5xz
+-----END PGP SIGNATURE-----
+`
+	gpgBinarySig = "iHUEABYIAB0WIQT1UfveAl2DihVeSVp0ILbOUlgmTwUCatLoHgAKCRB0ILbOUlgmT9zAAP0TcRZSDb+NhwClm62UBogx8lO3W49lB++DNzTbkBICfAD+Jir9HKruGUmoevLo1QTAZoGWacS6+ltDB82jpTdJsg0="
+)
+
+func TestPublicKeyType(t *testing.T) {
+	tests := []struct {
+		key     string
+		keyType string
+	}{
+		{minisignKey, "minisign"},
+		{"RWQ/VK4mEZOSnVFf2NhEt9WV8zE1RcN8mtKeOO7mVjj/MCDvb5tSV6RD", "minisign"},
+		{sshKey, "ssh"},
+		{gpgKey, "gpg"},
+		{"not a key", ""},
+	}
+	for _, tt := range tests {
+		keyType, err := publicKeyType(tt.key)
+		if keyType != tt.keyType || (tt.keyType == "") != (err != nil) {
+			t.Errorf("publicKeyType(%.20q) = %q, %v, want %q", tt.key, keyType, err, tt.keyType)
+		}
+	}
+}
+
+func TestVerifyDetached(t *testing.T) {
+	binarySig, err := base64.StdEncoding.DecodeString(gpgBinarySig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		keyType string
+		key     string
+		content string
+		sig     string
+		valid   bool
+	}{
+		{"minisign legacy", "minisign", minisignKey, minisignContent, minisignSig, true},
+		{"minisign prehashed", "minisign", minisignHashedKey, minisignContent, minisignHashedSig, true},
+		{"minisign tampered content", "minisign", minisignKey, "Hello, World?\n", minisignSig, false},
+		{"minisign prehashed tampered content", "minisign", minisignHashedKey, "Hello\n", minisignHashedSig, false},
+		{
+			"minisign tampered trusted comment", "minisign", minisignKey, minisignContent,
+			strings.Replace(minisignSig, "hello_world.txt", "evil.txt", 1), false,
+		},
+		{"minisign other key", "minisign", minisignHashedKey, minisignContent, minisignSig, false},
+		{"minisign malformed", "minisign", minisignKey, minisignContent, "garbage", false},
+		{"ssh", "ssh", sshKey, signedContent, sshSig, true},
+		{"ssh tampered content", "ssh", sshKey, "Hello, puff?\n", sshSig, false},
+		{"ssh other key", "ssh", sshOtherKey, signedContent, sshSig, false},
+		{"ssh other namespace", "ssh", sshKey, signedContent, sshGitSig, false},
+		{"ssh malformed", "ssh", sshKey, signedContent, "garbage", false},
+		{"gpg armored", "gpg", gpgKey, signedContent, gpgArmoredSig, true},
+		{"gpg binary", "gpg", gpgKey, signedContent, string(binarySig), true},
+		{"gpg tampered content", "gpg", gpgKey, "Hello, puff?\n", gpgArmoredSig, false},
+		{"gpg other key", "gpg", gpgOtherKey, signedContent, gpgArmoredSig, false},
+		{"unknown key type", "x509", gpgKey, signedContent, gpgArmoredSig, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verifyDetached(tt.keyType, strings.NewReader(tt.content), tt.key, []byte(tt.sig))
+			if tt.valid && err != nil {
+				t.Errorf("expected valid signature, got %v", err)
+			}
+			if !tt.valid && err == nil {
+				t.Error("expected invalid signature to be rejected")
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package puff
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// returns store directory holding files of package version
+func VersionDir(cfgDir string, path string, version string) string {
+	return filepath.Join(cfgDir, "store", filepath.FromSlash(path), version)
+}
+
+// returns target of bin symlink pointing to file in store,
+// relative so config directory can be moved
+func storeTarget(path string, version string, file string) string {
+	return filepath.Join("..", "store", filepath.FromSlash(path), version, file)
+}
+
+// checks if package may replace bin entry: it has to be missing,
+// a symlink into store directory of package or one pointing at its files,
+// regular files are accepted only among its files from before the store
+func checkBinOwner(cfgDir string, path string, name string, files []string) error {
+	binPath := filepath.Join(cfgDir, "bin", name)
+	fi, err := os.Lstat(binPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if fi.Mode().IsRegular() && slices.Contains(files, name) {
+		return nil
+	}
+	if fi.Mode()&fs.ModeSymlink != 0 {
+		link, err := os.Readlink(binPath)
+		if err != nil {
+			return err
+		}
+		storeDir := filepath.Join("..", "store", filepath.FromSlash(path)) + string(filepath.Separator)
+		if strings.HasPrefix(link, storeDir) || slices.Contains(files, link) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s in bin does not belong to %s, not replacing it", name, path)
+}
+
+// points bin symlinks of files to store directory of package version,
+// each symlink is replaced atomically, oldFiles are files installed
+// by previous version which may be regular files from before the store
+func ActivateVersion(cfgDir string, path string, version string, files []string, oldFiles []string) error {
+	for _, f := range files {
+		err := checkBinOwner(cfgDir, path, f, oldFiles)
+		if err != nil {
+			return err
+		}
+	}
+	for _, f := range files {
+		binPath := filepath.Join(cfgDir, "bin", f)
+		tempPath := binPath + ".tmp"
+		os.Remove(tempPath)
+		err := os.Symlink(storeTarget(path, version, f), tempPath)
+		if err != nil {
+			return err
+		}
+		err = os.Rename(tempPath, binPath)
+		if err != nil {
+			os.Remove(tempPath)
+			return err
+		}
+	}
+	fmt.Printf("%s %s linked into bin\n", path, version)
+	return nil
+}
+
+// hard links file to dst, copies it if linking is not possible
+func linkOrCopy(src string, dst string) error {
+	os.Remove(dst)
+	if os.Link(src, dst) == nil {
+		return nil
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0750)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, in)
+	if err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// moves package version installed before the store existed into store,
+// binaries written straight to bin are linked into its store directory
+func adoptLegacyVersion(cfgDir string, entry *Metadata) error {
+	dir := VersionDir(cfgDir, entry.Path, entry.Version)
+	_, err := os.Stat(dir)
+	if err == nil || !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	for _, f := range InstalledFiles(entry) {
+		binPath := filepath.Join(cfgDir, "bin", f)
+		fi, err := os.Lstat(binPath)
+		if err != nil || !fi.Mode().IsRegular() {
+			continue
+		}
+		err = os.MkdirAll(dir, 0750)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("moving %s into store\n", f)
+		err = linkOrCopy(binPath, filepath.Join(dir, f))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removes every version of package from store
+func removeFromStore(cfgDir string, path string) error {
+	return os.RemoveAll(filepath.Join(cfgDir, "store", filepath.FromSlash(path)))
+}
+
+// removes versions of package from store except the kept one
+func pruneStore(cfgDir string, path string, keep string) error {
+	dir := filepath.Join(cfgDir, "store", filepath.FromSlash(path))
+	versions, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, v := range versions {
+		if v.Name() == keep {
+			continue
+		}
+		err := os.RemoveAll(filepath.Join(dir, v.Name()))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package puff
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckBinOwner(t *testing.T) {
+	cfgDir := t.TempDir()
+	binDir := filepath.Join(cfgDir, "bin")
+	if err := os.Mkdir(binDir, 0750); err != nil {
+		t.Fatal(err)
+	}
+	links := map[string]string{
+		"tool":    storeTarget("owner/tool", "v1.0.0", "tool"),
+		"toolbox": storeTarget("owner/toolbox", "v1.0.0", "toolbox"),
+		"t":       "tool",
+		"tb":      "toolbox",
+	}
+	for name, target := range links {
+		if err := os.Symlink(target, filepath.Join(binDir, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(binDir, "legacy"), elfBin, 0750); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		path  string
+		name  string
+		files []string
+		owned bool
+	}{
+		{"owner/tool", "missing", nil, true},
+		{"owner/tool", "tool", nil, true},
+		{"owner/tool", "toolbox", nil, false},
+		{"owner/toolbox", "tool", nil, false},
+		{"owner/tool", "t", []string{"tool"}, true},
+		{"owner/tool", "tb", []string{"tool"}, false},
+		{"owner/legacy", "legacy", []string{"legacy"}, true},
+		{"owner/other", "legacy", []string{"other"}, false},
+	}
+	for _, tt := range tests {
+		err := checkBinOwner(cfgDir, tt.path, tt.name, tt.files)
+		if (err == nil) != tt.owned {
+			t.Errorf("checkBinOwner(%s, %s, %v) = %v, want owned %v", tt.path, tt.name, tt.files, err, tt.owned)
+		}
+	}
+}
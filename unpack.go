@@ -0,0 +1,340 @@
+package puff
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+// number of leading bytes needed to detect format of content
+const sniffLen = 512
+
+// returns last element of a path inside an archive
+func archiveBaseName(name string) string {
+	splitted := strings.Split(name, "/")
+	return splitted[len(splitted)-1]
+}
+
+// streams binary into temp file in destination directory
+// and atomically renames it over the old version
+func writeBinary(destDir string, binName string, r io.Reader) error {
+	savePath := filepath.Join(destDir, binName)
+	tempPath := savePath + ".tmp"
+	fmt.Printf("writing %s to %s\n", binName, savePath)
+	f, err := os.OpenFile(tempPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0750)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, r)
+	if err != nil {
+		f.Close()
+		os.Remove(tempPath)
+		return err
+	}
+	err = f.Close()
+	if err != nil {
+		os.Remove(tempPath)
+		return err
+	}
+	err = os.Rename(tempPath, savePath)
+	if err != nil {
+		os.Remove(tempPath)
+		return err
+	}
+	return nil
+}
+
+// returns reader decompressing content according to its compression
+func decompressor(r io.Reader, compression string) (io.ReadCloser, error) {
+	switch compression {
+	case "gz":
+		return gzip.NewReader(r)
+	case "xz":
+		xr, err := xz.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return io.NopCloser(xr), nil
+	case "bz2":
+		return io.NopCloser(bzip2.NewReader(r)), nil
+	case "zst":
+		zr, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return zr.IOReadCloser(), nil
+	}
+	return nil, fmt.Errorf("unsupported compression %s", compression)
+}
+
+// returns leading bytes of buffered reader without consuming them
+func peekHead(br *bufio.Reader) ([]byte, error) {
+	head, err := br.Peek(sniffLen)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	return head, nil
+}
+
+// decides if archive entry should be installed,
+// in all mode every executable ELF that is not a shared library qualifies
+func shouldInstall(name string, mode os.FileMode, head []byte, binNames []string, all bool) bool {
+	if slices.Contains(binNames, name) {
+		return true
+	}
+	if !all || mode&0111 == 0 || !bytes.HasPrefix(head, []byte("\x7fELF")) {
+		return false
+	}
+	return !strings.HasSuffix(name, ".so") && !strings.Contains(name, ".so.")
+}
+
+// orders installed files as requested in binNames,
+// so the main binary goes first
+func sortInstalled(installed []string, binNames []string) {
+	slices.SortStableFunc(installed, func(a, b string) int {
+		ia, ib := slices.Index(binNames, a), slices.Index(binNames, b)
+		if ia == -1 {
+			ia = len(binNames)
+		}
+		if ib == -1 {
+			ib = len(binNames)
+		}
+		return ia - ib
+	})
+}
+
+// returns error listing requested binaries missing in archive
+func checkAllInstalled(installed []string, binNames []string, all bool) error {
+	if all {
+		if len(installed) == 0 {
+			return errors.New("no executables found in archive")
+		}
+		return nil
+	}
+	var missing []string
+	for _, name := range binNames {
+		if !slices.Contains(installed, name) {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("binaries not found in archive: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// streams tarball searching for binaries and writes them to destDir
+func unpackTar(destDir string, r io.Reader, binNames []string, all bool) ([]string, error) {
+	var installed []string
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break // End of archive
+		}
+		if err != nil {
+			return installed, err
+		}
+		name := archiveBaseName(hdr.Name)
+		if hdr.Typeflag != tar.TypeReg || slices.Contains(installed, name) {
+			continue
+		}
+		if !slices.Contains(binNames, name) && !all {
+			continue
+		}
+		br := bufio.NewReader(tr)
+		head, err := peekHead(br)
+		if err != nil {
+			return installed, err
+		}
+		if !shouldInstall(name, hdr.FileInfo().Mode(), head, binNames, all) {
+			continue
+		}
+		err = writeBinary(destDir, name, br)
+		if err != nil {
+			return installed, err
+		}
+		installed = append(installed, name)
+	}
+	sortInstalled(installed, binNames)
+	return installed, checkAllInstalled(installed, binNames, all)
+}
+
+// installs single .zip entry if it's wanted,
+// returns false if entry was skipped
+func unpackZipEntry(destDir string, f *zip.File, name string, binNames []string, all bool) (bool, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return false, err
+	}
+	defer rc.Close()
+	br := bufio.NewReader(rc)
+	head, err := peekHead(br)
+	if err != nil {
+		return false, err
+	}
+	mode := f.Mode()
+	if f.CreatorVersion>>8 != 3 {
+		// no unix permissions stored in archives made outside unix
+		mode |= 0111
+	}
+	if !shouldInstall(name, mode, head, binNames, all) {
+		return false, nil
+	}
+	return true, writeBinary(destDir, name, br)
+}
+
+// searches .zip for binaries and writes them to destDir
+func unpackZip(destDir string, r io.ReaderAt, size int64, binNames []string, all bool) ([]string, error) {
+	var installed []string
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return installed, err
+	}
+	for _, f := range zr.File {
+		name := archiveBaseName(f.Name)
+		// directories and symlinks are skipped, symlink content is its target path
+		if !f.Mode().IsRegular() || slices.Contains(installed, name) {
+			continue
+		}
+		if !slices.Contains(binNames, name) && !all {
+			continue
+		}
+		written, err := unpackZipEntry(destDir, f, name, binNames, all)
+		if err != nil {
+			return installed, err
+		}
+		if written {
+			installed = append(installed, name)
+		}
+	}
+	sortInstalled(installed, binNames)
+	return installed, checkAllInstalled(installed, binNames, all)
+}
+
+// magic bytes of supported asset formats,
+// checked in order so AppImage goes before plain ELF
+var magicFormats = []struct {
+	Offset int
+	Magic  []byte
+	Format string
+}{
+	{0, []byte{0x1f, 0x8b}, "gz"},
+	{0, []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}, "xz"},
+	{0, []byte("BZh"), "bz2"},
+	{0, []byte{0x28, 0xb5, 0x2f, 0xfd}, "zst"},
+	{0, []byte("PK\x03\x04"), "zip"},
+	{8, []byte("AI\x02"), "appimage"},
+	{0, []byte("\x7fELF"), "elf"},
+	{257, []byte("ustar"), "tar"},
+	{0, []byte("#!"), "script"},
+}
+
+// detects asset format from leading bytes of its content
+func detectFormat(head []byte) (string, error) {
+	for _, m := range magicFormats {
+		if len(head) < m.Offset+len(m.Magic) {
+			continue
+		}
+		if bytes.Equal(head[m.Offset:m.Offset+len(m.Magic)], m.Magic) {
+			if m.Format == "appimage" && !bytes.HasPrefix(head, []byte("\x7fELF")) {
+				continue
+			}
+			return m.Format, nil
+		}
+	}
+	text := strings.ToLower(strings.TrimSpace(string(head[:min(len(head), sniffLen)])))
+	if strings.HasPrefix(text, "<!doctype html") || strings.HasPrefix(text, "<html") {
+		return "", errors.New("downloaded content is an HTML page, not a binary or archive")
+	}
+	if strings.HasPrefix(text, "{") || strings.HasPrefix(text, "<?xml") {
+		return "", errors.New("downloaded content is a text document, not a binary or archive")
+	}
+	return "", errors.New("unrecognized format of downloaded content")
+}
+
+// saves binary directly to destDir, decompresses it or unpacks it if it's an archive,
+// format is detected from content instead of asset name,
+// binNames are installed from archives (or every executable in all mode),
+// a bare binary is installed as first of binNames,
+// content is streamed from downloaded file at assetPath,
+// returns names of written files
+func saveOrUnpack(
+	destDir string,
+	assetPath string,
+	binNames []string,
+	all bool,
+	assetName string,
+) ([]string, error) {
+	f, err := os.Open(assetPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	br := bufio.NewReader(f)
+	head, err := peekHead(br)
+	if err != nil {
+		return nil, err
+	}
+	format, err := detectFormat(head)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", assetName, err)
+	}
+	var r io.Reader = br
+	switch format {
+	case "gz", "xz", "bz2", "zst":
+		fmt.Printf("decompressing %s\n", assetName)
+		dr, err := decompressor(br, format)
+		if err != nil {
+			return nil, err
+		}
+		defer dr.Close()
+		dbr := bufio.NewReader(dr)
+		head, err = peekHead(dbr)
+		if err != nil {
+			return nil, err
+		}
+		// compressed payload is either a tarball or a bare binary
+		format, err = detectFormat(head)
+		if err != nil {
+			return nil, fmt.Errorf("%s: after decompressing: %w", assetName, err)
+		}
+		if format == "tar" {
+			fmt.Printf("unpacking %s\n", assetName)
+			return unpackTar(destDir, dbr, binNames, all)
+		}
+		if format != "elf" && format != "appimage" && format != "script" {
+			return nil, fmt.Errorf("%s: unsupported %s content inside compressed asset", assetName, format)
+		}
+		r = dbr
+	case "tar":
+		fmt.Printf("unpacking %s\n", assetName)
+		return unpackTar(destDir, br, binNames, all)
+	case "zip":
+		fmt.Printf("unpacking %s\n", assetName)
+		return unpackZip(destDir, f, fi.Size(), binNames, all)
+	}
+	// save directly
+	err = writeBinary(destDir, binNames[0], r)
+	if err != nil {
+		return nil, err
+	}
+	return binNames[:1], nil
+}
@@ -0,0 +1,239 @@
+package puff
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+// fake executable, only its ELF magic matters
+var elfBin = []byte("\x7fELF\x02\x01\x01\x00fake binary\n")
+
+// elfBin compressed with bzip2, there is no bzip2 writer in standard library
+const elfBinBz2 = "QlpoOTFBWSZTWciHLr8AAAnVgHAQQAADBDMpECCgACKaGg0ybKFNMjExMRKWyOlmgOHmQhPxdyRThQkMiHLr8A=="
+
+type archiveFile struct {
+	Name string
+	Mode os.FileMode
+	Body []byte
+}
+
+// returns executable ELF entry of archive
+func binEntry(name string) archiveFile {
+	return archiveFile{name, 0755, elfBin}
+}
+
+func makeTar(t *testing.T, files ...archiveFile) []byte {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, f := range files {
+		hdr := &tar.Header{Name: f.Name, Mode: int64(f.Mode.Perm()), Size: int64(len(f.Body))}
+		if f.Mode&os.ModeSymlink != 0 {
+			hdr = &tar.Header{Name: f.Name, Typeflag: tar.TypeSymlink, Linkname: string(f.Body), Mode: 0777}
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if hdr.Typeflag != tar.TypeSymlink {
+			if _, err := tw.Write(f.Body); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func makeZip(t *testing.T, files ...archiveFile) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, f := range files {
+		hdr := &zip.FileHeader{Name: f.Name, Method: zip.Deflate}
+		hdr.SetMode(f.Mode)
+		w, err := zw.CreateHeader(hdr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write(f.Body); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func compress(t *testing.T, format string, data []byte) []byte {
+	var buf bytes.Buffer
+	var err error
+	switch format {
+	case "gz":
+		w := gzip.NewWriter(&buf)
+		_, err = w.Write(data)
+		if err == nil {
+			err = w.Close()
+		}
+	case "xz":
+		w, werr := xz.NewWriter(&buf)
+		if werr != nil {
+			t.Fatal(werr)
+		}
+		_, err = w.Write(data)
+		if err == nil {
+			err = w.Close()
+		}
+	case "zst":
+		w, werr := zstd.NewWriter(&buf)
+		if werr != nil {
+			t.Fatal(werr)
+		}
+		_, err = w.Write(data)
+		if err == nil {
+			err = w.Close()
+		}
+	default:
+		t.Fatalf("unknown compression %s", format)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDetectFormat(t *testing.T) {
+	appImage := append([]byte("\x7fELF\x02\x01\x01\x00AI\x02"), make([]byte, 16)...)
+	tests := []struct {
+		name   string
+		head   []byte
+		format string
+	}{
+		{"gzip", compress(t, "gz", elfBin), "gz"},
+		{"xz", compress(t, "xz", elfBin), "xz"},
+		{"zstd", compress(t, "zst", elfBin), "zst"},
+		{"bzip2", []byte("BZh91AY&SY"), "bz2"},
+		{"zip", makeZip(t, binEntry("tool")), "zip"},
+		{"tar", makeTar(t, binEntry("tool")), "tar"},
+		{"elf", elfBin, "elf"},
+		{"appimage", appImage, "appimage"},
+		{"appimage magic without elf", appImage[4:], ""},
+		{"script", []byte("#!/bin/sh\necho hi\n"), "script"},
+		{"html", []byte("\n  <!DOCTYPE html><html><body>Not Found</body></html>"), ""},
+		{"json", []byte(`{"message": "Not Found"}`), ""},
+		{"text", []byte("Not Found"), ""},
+		{"empty", nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			head := tt.head[:min(len(tt.head), sniffLen)]
+			format, err := detectFormat(head)
+			if format != tt.format || (tt.format == "") != (err != nil) {
+				t.Errorf("detectFormat = %q, %v, want %q", format, err, tt.format)
+			}
+		})
+	}
+}
+
+func TestSaveOrUnpack(t *testing.T) {
+	bz2, err := base64.StdEncoding.DecodeString(elfBinBz2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	script := []byte("#!/bin/sh\necho hi\n")
+	tarball := makeTar(t,
+		archiveFile{"tool-1.0/README.md", 0644, []byte("# tool\n")},
+		binEntry("tool-1.0/tool"),
+	)
+	multi := makeTar(t,
+		binEntry("toolctl"),
+		archiveFile{"lib/libtool.so.1", 0755, elfBin},
+		archiveFile{"install.sh", 0755, script},
+		archiveFile{"LICENSE", 0644, []byte("MIT\n")},
+		binEntry("tool"),
+		archiveFile{"completions/tool", 0644, []byte("complete tool\n")},
+	)
+	tests := []struct {
+		name      string
+		asset     []byte
+		binNames  []string
+		all       bool
+		installed []string
+		body      []byte
+	}{
+		{"bare binary", elfBin, []string{"tool"}, false, []string{"tool"}, elfBin},
+		{"bare binary gets first name", elfBin, []string{"tool", "toolctl"}, false, []string{"tool"}, elfBin},
+		{"script", script, []string{"tool"}, false, []string{"tool"}, script},
+		{"gzip binary", compress(t, "gz", elfBin), []string{"tool"}, false, []string{"tool"}, elfBin},
+		{"bzip2 binary", bz2, []string{"tool"}, false, []string{"tool"}, elfBin},
+		{"tar", tarball, []string{"tool"}, false, []string{"tool"}, elfBin},
+		{"tar.gz", compress(t, "gz", tarball), []string{"tool"}, false, []string{"tool"}, elfBin},
+		{"tar.xz", compress(t, "xz", tarball), []string{"tool"}, false, []string{"tool"}, elfBin},
+		{"tar.zst", compress(t, "zst", tarball), []string{"tool"}, false, []string{"tool"}, elfBin},
+		{"zip", makeZip(t, archiveFile{"tool-1.0/README.md", 0644, []byte("# tool\n")}, binEntry("tool-1.0/tool")), []string{"tool"}, false, []string{"tool"}, elfBin},
+		{"missing binary", tarball, []string{"other"}, false, nil, nil},
+		{"one of binaries missing", tarball, []string{"tool", "toolctl"}, false, nil, nil},
+		{"named binaries in requested order", multi, []string{"tool", "toolctl"}, false, []string{"tool", "toolctl"}, elfBin},
+		{"all executables", multi, []string{"tool"}, true, []string{"tool", "toolctl"}, elfBin},
+		{"all executables in zip", makeZip(t, binEntry("toolctl"), archiveFile{"LICENSE", 0644, []byte("MIT\n")}, binEntry("tool")), []string{"tool"}, true, []string{"tool", "toolctl"}, elfBin},
+		{"all mode without executables", makeTar(t, archiveFile{"LICENSE", 0644, []byte("MIT\n")}), []string{"tool"}, true, nil, nil},
+		{"tar symlink is skipped", makeTar(t, archiveFile{"tool", os.ModeSymlink, []byte("/etc/passwd")}), []string{"tool"}, false, nil, nil},
+		{"zip symlink is skipped", makeZip(t, archiveFile{"tool", os.ModeSymlink | 0777, []byte("/etc/passwd")}), []string{"tool"}, false, nil, nil},
+		{"html page", []byte("<!DOCTYPE html><html></html>"), []string{"tool"}, false, nil, nil},
+		{"gzip of html page", compress(t, "gz", []byte("<html></html>")), []string{"tool"}, false, nil, nil},
+		{"gzip of zip", compress(t, "gz", makeZip(t, binEntry("tool"))), []string{"tool"}, false, nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			destDir := t.TempDir()
+			assetPath := filepath.Join(t.TempDir(), "asset")
+			if err := os.WriteFile(assetPath, tt.asset, 0600); err != nil {
+				t.Fatal(err)
+			}
+			installed, err := saveOrUnpack(destDir, assetPath, tt.binNames, tt.all, "asset")
+			if tt.installed == nil {
+				if err == nil {
+					t.Errorf("expected error, installed %v", installed)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(installed, tt.installed) {
+				t.Errorf("installed %v, want %v", installed, tt.installed)
+			}
+			entries, err := os.ReadDir(destDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != len(tt.installed) {
+				var names []string
+				for _, e := range entries {
+					names = append(names, e.Name())
+				}
+				t.Errorf("files written %s, want %v", strings.Join(names, ", "), tt.installed)
+			}
+			for _, name := range tt.installed {
+				body, err := os.ReadFile(filepath.Join(destDir, name))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(body, tt.body) {
+					t.Errorf("%s contains %q, want %q", name, body, tt.body)
+				}
+			}
+		})
+	}
+}
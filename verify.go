@@ -0,0 +1,134 @@
+package puff
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// suffixes of per-asset checksum files, like tool.tar.gz.sha256
+var checksumSuffixes = []string{".sha256", ".sha256sum", ".sha256.txt"}
+
+// names of release assets listing checksums of other assets,
+// like SHA256SUMS or tool_1.2.3_checksums.txt but not tool.tar.gz.sha256sum
+var checksumListRegexp = regexp.MustCompile(`(?i)(^|[-_])(sha256sums?|checksums?)(\.txt)?$`)
+
+// matches sha256 written as hex
+var sha256Regexp = regexp.MustCompile(`^[0-9a-fA-F]{64}$`)
+
+// matches BSD style checksum line, like "SHA256 (name) = <hex>"
+var bsdChecksumRegexp = regexp.MustCompile(`^SHA256 \((.+)\) = ([0-9a-fA-F]{64})$`)
+
+// returns sha256 of file as hex string
+func fileSha256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	_, err = io.Copy(h, f)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// searches checksum list in GNU ("<hex>  <name>") or BSD format for asset,
+// hash with no name is taken only from per-asset files (bare set),
+// returns empty string if asset is not listed
+func findInChecksumList(data []byte, assetName string, bare bool) string {
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if m := bsdChecksumRegexp.FindStringSubmatch(line); m != nil {
+			if archiveBaseName(m[1]) == assetName {
+				return strings.ToLower(m[2])
+			}
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 || !sha256Regexp.MatchString(fields[0]) {
+			continue
+		}
+		if len(fields) == 1 {
+			if bare {
+				return strings.ToLower(fields[0])
+			}
+			continue
+		}
+		name := strings.TrimPrefix(strings.Join(fields[1:], " "), "*")
+		if archiveBaseName(name) == assetName {
+			return strings.ToLower(fields[0])
+		}
+	}
+	return ""
+}
+
+// finds published sha256 of release asset,
+// returns it with description of its source or empty strings if none found
+func FindChecksum(release *Release, ghPat string) (string, string, error) {
+	assetName := release.Name
+	if digest, found := strings.CutPrefix(release.Digest, "sha256:"); found {
+		return strings.ToLower(digest), "API asset digest", nil
+	}
+	for _, suffix := range checksumSuffixes {
+		for _, asset := range release.Assets {
+			if asset.Name != assetName+suffix {
+				continue
+			}
+			data, err := DownloadSmallAsset(asset.URL, ghPat)
+			if err != nil {
+				return "", "", err
+			}
+			if sum := findInChecksumList(data, assetName, true); sum != "" {
+				return sum, asset.Name, nil
+			}
+		}
+	}
+	for _, asset := range release.Assets {
+		if !checksumListRegexp.MatchString(asset.Name) {
+			continue
+		}
+		data, err := DownloadSmallAsset(asset.URL, ghPat)
+		if err != nil {
+			return "", "", err
+		}
+		if sum := findInChecksumList(data, assetName, false); sum != "" {
+			return sum, asset.Name, nil
+		}
+	}
+	return "", "", nil
+}
+
+// verifies downloaded asset against published checksum,
+// returns verified sha256 or empty string if release has no checksum
+func VerifyChecksum(assetPath string, release *Release, ghPat string) (string, error) {
+	assetName := release.Name
+	expected, source, err := FindChecksum(release, ghPat)
+	if err != nil {
+		return "", fmt.Errorf("getting checksum for %s: %w", assetName, err)
+	}
+	if expected == "" {
+		fmt.Printf("no checksum published for %s, skipping verification\n", assetName)
+		return "", nil
+	}
+	actual, err := fileSha256(assetPath)
+	if err != nil {
+		return "", err
+	}
+	if actual != expected {
+		return "", fmt.Errorf(
+			"checksum mismatch for %s: expected %s (from %s), got %s, refusing to install",
+			assetName,
+			expected,
+			source,
+			actual,
+		)
+	}
+	fmt.Printf("sha256 of %s verified against %s\n", assetName, source)
+	return actual, nil
+}
@@ -0,0 +1,61 @@
+package puff
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFindInChecksumList(t *testing.T) {
+	hash := strings.Repeat("ab", 32)
+	other := strings.Repeat("cd", 32)
+	tests := []struct {
+		name  string
+		data  string
+		bare  bool
+		asset string
+		hash  string
+	}{
+		{"gnu", other + "  tool_linux_arm64.tar.gz\n" + hash + "  tool_linux_amd64.tar.gz\n", false, "tool_linux_amd64.tar.gz", hash},
+		{"gnu binary mode", hash + " *tool_linux_amd64.tar.gz\n", false, "tool_linux_amd64.tar.gz", hash},
+		{"gnu with directory", hash + "  ./dist/tool_linux_amd64.tar.gz\n", false, "tool_linux_amd64.tar.gz", hash},
+		{"gnu uppercase hash", strings.ToUpper(hash) + "  tool_linux_amd64.tar.gz\n", false, "tool_linux_amd64.tar.gz", hash},
+		{"gnu name with spaces", hash + "  tool linux.tar.gz\n", false, "tool linux.tar.gz", hash},
+		{"crlf line endings", other + "  tool.zip\r\n" + hash + "  tool.tar.gz\r\n", false, "tool.tar.gz", hash},
+		{"bsd", "SHA256 (tool.zip) = " + other + "\nSHA256 (tool.tar.gz) = " + hash + "\n", false, "tool.tar.gz", hash},
+		{"not listed", hash + "  tool_linux_arm64.tar.gz\n", false, "tool_linux_amd64.tar.gz", ""},
+		{"name is a suffix of other", hash + "  mytool.tar.gz\n", false, "tool.tar.gz", ""},
+		{"short hash", "abcdef  tool.tar.gz\n", false, "tool.tar.gz", ""},
+		{"bare hash in per-asset file", hash + "\n", true, "tool.tar.gz", hash},
+		{"bare hash in list", hash + "\n", false, "tool.tar.gz", ""},
+		{"named line in per-asset file", hash + "  tool.tar.gz\n", true, "tool.tar.gz", hash},
+		{"empty", "", true, "tool.tar.gz", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if found := findInChecksumList([]byte(tt.data), tt.asset, tt.bare); found != tt.hash {
+				t.Errorf("findInChecksumList = %q, want %q", found, tt.hash)
+			}
+		})
+	}
+}
+
+func TestChecksumListRegexp(t *testing.T) {
+	tests := []struct {
+		name string
+		list bool
+	}{
+		{"SHA256SUMS", true},
+		{"sha256sum.txt", true},
+		{"checksums.txt", true},
+		{"tool_1.2.3_checksums.txt", true},
+		{"tool-1.2.3-SHA256SUMS", true},
+		{"tool.tar.gz.sha256sum", false},
+		{"tool.tar.gz.sha256", false},
+		{"tool_linux_amd64.tar.gz", false},
+	}
+	for _, tt := range tests {
+		if list := checksumListRegexp.MatchString(tt.name); list != tt.list {
+			t.Errorf("checksumListRegexp matching %q = %v, want %v", tt.name, list, tt.list)
+		}
+	}
+}